@@ -0,0 +1,140 @@
+package alerts
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"web-scrapper/i18n"
+	"web-scrapper/models"
+)
+
+// severityRank orders alerts with the most disruptive first.
+var severityRank = map[string]int{
+	models.SeverityCritical: 0,
+	models.SeverityWarning:  1,
+	models.SeverityInfo:     2,
+}
+
+// current holds the alerts that have not ended, loaded by Load. Alerts whose
+// window has not started yet are kept so they activate on time.
+var current = struct {
+	sync.RWMutex
+	alerts []models.ServiceAlert
+}{}
+
+var (
+	hooksMu     sync.Mutex
+	createHooks []func(models.ServiceAlert)
+)
+
+// OnCreate registers fn to run after an alert has been created, e.g. to push
+// it to WebSocket clients.
+func OnCreate(fn func(models.ServiceAlert)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	createHooks = append(createHooks, fn)
+}
+
+func runCreateHooks(alert models.ServiceAlert) {
+	hooksMu.Lock()
+	hooks := append([]func(models.ServiceAlert){}, createHooks...)
+	hooksMu.Unlock()
+
+	for _, hook := range hooks {
+		hook(alert)
+	}
+}
+
+// Load reads the alerts that have not ended yet into memory. It is called at
+// startup, after every change and periodically by Watch so that changes made
+// on other replicas show up too.
+func Load(db *sql.DB) error {
+	alerts, err := query(db, "SELECT "+columns+" FROM service_alerts WHERE ends_at IS NULL OR ends_at > NOW()")
+	if err != nil {
+		return fmt.Errorf("error loading alerts: %v", err)
+	}
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if severityRank[alerts[i].Severity] != severityRank[alerts[j].Severity] {
+			return severityRank[alerts[i].Severity] < severityRank[alerts[j].Severity]
+		}
+		return alerts[i].StartsAt.After(alerts[j].StartsAt)
+	})
+
+	current.Lock()
+	current.alerts = alerts
+	current.Unlock()
+	return nil
+}
+
+func reload(db *sql.DB) {
+	if err := Load(db); err != nil {
+		log.Println(err)
+	}
+}
+
+// Watch reloads the alerts every interval. It blocks for the lifetime of the
+// process.
+func Watch(db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		reload(db)
+	}
+}
+
+// Active returns the alerts in effect at now, most disruptive first.
+func Active(now time.Time) []models.ServiceAlert {
+	current.RLock()
+	defer current.RUnlock()
+
+	active := []models.ServiceAlert{}
+	for _, alert := range current.alerts {
+		if !alert.StartsAt.After(now) && (alert.EndsAt == nil || alert.EndsAt.After(now)) {
+			active = append(active, alert)
+		}
+	}
+	return active
+}
+
+// ForStation returns the alerts in effect at now that affect a station, in
+// one direction when arah is set.
+func ForStation(now time.Time, stationID int, arah string) []models.ServiceAlert {
+	matching := []models.ServiceAlert{}
+	for _, alert := range Active(now) {
+		if affects(alert, stationID, arah) {
+			matching = append(matching, alert)
+		}
+	}
+	return matching
+}
+
+func affects(alert models.ServiceAlert, stationID int, arah string) bool {
+	stationMatch := len(alert.StationIDs) == 0
+	for _, id := range alert.StationIDs {
+		stationMatch = stationMatch || id == stationID
+	}
+	directionMatch := arah == "" || len(alert.Directions) == 0
+	for _, direction := range alert.Directions {
+		directionMatch = directionMatch || direction == arah
+	}
+	return stationMatch && directionMatch
+}
+
+// Localize sets the Message of each alert to its text in lang. Alerts without
+// an English text, such as the ones imported from the operator website, keep
+// the Indonesian one.
+func Localize(alerts []models.ServiceAlert, lang string) []models.ServiceAlert {
+	localized := make([]models.ServiceAlert, len(alerts))
+	for i, alert := range alerts {
+		alert.Message = alert.MessageID
+		if lang == i18n.EN && alert.MessageEN != "" {
+			alert.Message = alert.MessageEN
+		}
+		localized[i] = alert
+	}
+	return localized
+}
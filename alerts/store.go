@@ -0,0 +1,214 @@
+package alerts
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"web-scrapper/models"
+
+	"github.com/lib/pq"
+)
+
+const columns = "id, station_ids, directions, severity, message_id, message_en, starts_at, ends_at, created_by, COALESCE(source_url, ''), created_at, updated_at"
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scan(row scanner) (models.ServiceAlert, error) {
+	var alert models.ServiceAlert
+	var stationIDs pq.Int64Array
+	var directions pq.StringArray
+	var endsAt sql.NullTime
+	var createdBy sql.NullInt64
+	err := row.Scan(&alert.ID, &stationIDs, &directions, &alert.Severity, &alert.MessageID, &alert.MessageEN,
+		&alert.StartsAt, &endsAt, &createdBy, &alert.SourceURL, &alert.CreatedAt, &alert.UpdatedAt)
+	if err != nil {
+		return alert, err
+	}
+
+	alert.StationIDs = make([]int, len(stationIDs))
+	for i, id := range stationIDs {
+		alert.StationIDs[i] = int(id)
+	}
+	alert.Directions = []string(directions)
+	if alert.Directions == nil {
+		alert.Directions = []string{}
+	}
+	if endsAt.Valid {
+		alert.EndsAt = &endsAt.Time
+	}
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		alert.CreatedBy = &id
+	}
+	return alert, nil
+}
+
+func query(db *sql.DB, query string, args ...interface{}) ([]models.ServiceAlert, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []models.ServiceAlert{}
+	for rows.Next() {
+		alert, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
+
+func stationArray(ids []int) pq.Int64Array {
+	array := make(pq.Int64Array, len(ids))
+	for i, id := range ids {
+		array[i] = int64(id)
+	}
+	return array
+}
+
+func directionArray(directions []string) pq.StringArray {
+	if directions == nil {
+		return pq.StringArray{}
+	}
+	return pq.StringArray(directions)
+}
+
+// List returns one page of every alert, ended ones included, newest first,
+// and the total number of alerts.
+func List(db *sql.DB, limit int, offset int) ([]models.ServiceAlert, int, error) {
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM service_alerts").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	alerts, err := query(db, "SELECT "+columns+" FROM service_alerts ORDER BY starts_at DESC, id DESC LIMIT $1 OFFSET $2", limit, offset)
+	return alerts, total, err
+}
+
+// Get returns the alert with the given ID, or sql.ErrNoRows.
+func Get(db *sql.DB, id int) (models.ServiceAlert, error) {
+	return scan(db.QueryRow("SELECT "+columns+" FROM service_alerts WHERE id = $1", id))
+}
+
+// Create stores a new alert, reloads the active alerts and runs the OnCreate
+// hooks. createdBy is the ID of the admin, or 0 when the alert was not
+// created by a user.
+func Create(db *sql.DB, input models.ServiceAlertInput, createdBy int) (models.ServiceAlert, error) {
+	return insert(db, input, createdBy, "", "")
+}
+
+// Import stores an alert taken from a page of the operator website unless it
+// is already known or already ended. An alert from the same page is updated
+// when its content changed, and content already imported from another page
+// is skipped. Replicas may import the same page concurrently; only one of
+// them creates the alert. It reports whether a new alert was created.
+func Import(db *sql.DB, input models.ServiceAlertInput, sourceURL string, contentHash string) (bool, error) {
+	// Old news would otherwise be published as new alerts on the first run.
+	if input.EndsAt != nil && !input.EndsAt.After(time.Now()) {
+		return false, nil
+	}
+
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM service_alerts WHERE content_hash = $1 AND source_url <> $2)", contentHash, sourceURL).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("error looking up imported alert: %v", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	_, err = insert(db, input, 0, sourceURL, contentHash)
+	if err == nil {
+		return true, nil
+	}
+	if err != errAlreadyImported {
+		return false, err
+	}
+
+	result, err := db.Exec(`
+		UPDATE service_alerts
+		SET severity = $2, message_id = $3, message_en = $4, content_hash = $5, updated_at = NOW()
+		WHERE source_url = $1 AND content_hash IS DISTINCT FROM $5`,
+		sourceURL, input.Severity, input.MessageID, input.MessageEN, contentHash)
+	if err != nil {
+		return false, fmt.Errorf("error updating imported alert: %v", err)
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated > 0 {
+		reload(db)
+	}
+	return false, nil
+}
+
+// errAlreadyImported is returned by insert when an alert from the same page
+// of the operator website exists.
+var errAlreadyImported = errors.New("alert already imported")
+
+func insert(db *sql.DB, input models.ServiceAlertInput, createdBy int, sourceURL string, contentHash string) (models.ServiceAlert, error) {
+	startsAt := time.Now()
+	if input.StartsAt != nil {
+		startsAt = *input.StartsAt
+	}
+	var creator sql.NullInt64
+	if createdBy != 0 {
+		creator = sql.NullInt64{Int64: int64(createdBy), Valid: true}
+	}
+
+	alert, err := scan(db.QueryRow(`
+		INSERT INTO service_alerts (station_ids, directions, severity, message_id, message_en, starts_at, ends_at, created_by, source_url, content_hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''))
+		ON CONFLICT (source_url) DO NOTHING
+		RETURNING `+columns,
+		stationArray(input.StationIDs), directionArray(input.Directions), input.Severity, input.MessageID, input.MessageEN,
+		startsAt, input.EndsAt, creator, sourceURL, contentHash))
+	if err == sql.ErrNoRows {
+		return alert, errAlreadyImported
+	}
+	if err != nil {
+		return alert, fmt.Errorf("error creating alert: %v", err)
+	}
+
+	reload(db)
+	runCreateHooks(alert)
+	return alert, nil
+}
+
+// Update replaces the content of an alert and reloads the active alerts. The
+// start is kept when input has none. It returns sql.ErrNoRows when the alert
+// does not exist.
+func Update(db *sql.DB, id int, input models.ServiceAlertInput) (models.ServiceAlert, error) {
+	alert, err := scan(db.QueryRow(`
+		UPDATE service_alerts
+		SET station_ids = $2, directions = $3, severity = $4, message_id = $5, message_en = $6,
+			starts_at = COALESCE($7, starts_at), ends_at = $8, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+columns,
+		id, stationArray(input.StationIDs), directionArray(input.Directions), input.Severity, input.MessageID, input.MessageEN,
+		input.StartsAt, input.EndsAt))
+	if err != nil {
+		return alert, err
+	}
+
+	reload(db)
+	return alert, nil
+}
+
+// Delete removes an alert and reloads the active alerts. It returns
+// sql.ErrNoRows when the alert does not exist.
+func Delete(db *sql.DB, id int) error {
+	result, err := db.Exec("DELETE FROM service_alerts WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	reload(db)
+	return nil
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"web-scrapper/models"
+
+	"github.com/lib/pq"
+)
+
+// apiKeyPrefix starts every API key so leaked keys are easy to recognize.
+const apiKeyPrefix = "mrt_"
+
+// prefixLength is the number of leading characters of a key kept in clear
+// to tell keys apart.
+const prefixLength = 12
+
+// lastUsedPrecision limits how often the last use of a key is written.
+const lastUsedPrecision = time.Minute
+
+// ErrInvalidAPIKey is returned for unknown, expired and revoked API keys.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+const apiKeyColumns = "id, name, prefix, scopes, created_by, created_at, last_used_at, expires_at, revoked_at"
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row scanner) (models.APIKey, error) {
+	var key models.APIKey
+	var scopes pq.StringArray
+	var createdBy sql.NullInt64
+	var lastUsedAt, expiresAt, revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &scopes, &createdBy, &key.CreatedAt, &lastUsedAt, &expiresAt, &revokedAt)
+	if err != nil {
+		return key, err
+	}
+
+	key.Scopes = []string(scopes)
+	if key.Scopes == nil {
+		key.Scopes = []string{}
+	}
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		key.CreatedBy = &id
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
+
+// CreateAPIKey generates an API key. The key itself is only in the result;
+// the database keeps its hash.
+func CreateAPIKey(db *sql.DB, input models.APIKeyInput, createdBy int) (models.APIKey, error) {
+	secret, err := randomString(32)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	plain := apiKeyPrefix + secret
+	var creator sql.NullInt64
+	if createdBy != 0 {
+		creator = sql.NullInt64{Int64: int64(createdBy), Valid: true}
+	}
+
+	key, err := scanAPIKey(db.QueryRow(`
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+apiKeyColumns,
+		input.Name, plain[:prefixLength], hashToken(plain), pq.StringArray(input.Scopes), creator, input.ExpiresAt))
+	if err != nil {
+		return key, fmt.Errorf("error creating API key: %v", err)
+	}
+	key.Key = plain
+	return key, nil
+}
+
+// ListAPIKeys returns one page of the API keys, revoked ones included, newest
+// first, and their total number.
+func ListAPIKeys(db *sql.DB, limit int, offset int) ([]models.APIKey, int, error) {
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM api_keys").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Query("SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id DESC LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, total, rows.Err()
+}
+
+// RevokeAPIKey stops an API key from working. The key stays listed. It
+// returns sql.ErrNoRows when the key does not exist.
+func RevokeAPIKey(db *sql.DB, id int) (models.APIKey, error) {
+	return scanAPIKey(db.QueryRow(`
+		UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1
+		RETURNING `+apiKeyColumns, id))
+}
+
+// AuthenticateAPIKey returns the key matching a X-API-Key header and records
+// its use.
+func AuthenticateAPIKey(db *sql.DB, plain string) (models.APIKey, error) {
+	key, err := scanAPIKey(db.QueryRow(`
+		SELECT `+apiKeyColumns+` FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())`,
+		hashToken(plain)))
+	if err == sql.ErrNoRows {
+		return key, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return key, fmt.Errorf("error looking up API key: %v", err)
+	}
+
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > lastUsedPrecision {
+		if _, err := db.Exec("UPDATE api_keys SET last_used_at = NOW() WHERE id = $1", key.ID); err != nil {
+			return key, fmt.Errorf("error recording API key use: %v", err)
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"regexp"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// usernamePattern allows ASCII letters, digits, '.', '_' and '-', starting
+// with a letter or digit. Lengths are checked by the binding tags.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// maxPasswordBytes is the most bcrypt hashes; longer passwords would be
+// silently truncated.
+const maxPasswordBytes = 72
+
+func init() {
+	// Register the "username" and "password" tags used by the request
+	// models.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+			return ValidUsername(fl.Field().String())
+		})
+		v.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+			return StrongPassword(fl.Field().String())
+		})
+	}
+}
+
+// ValidUsername reports whether a username only uses the allowed
+// characters.
+func ValidUsername(username string) bool {
+	return usernamePattern.MatchString(username)
+}
+
+// StrongPassword reports whether a password mixes letters and digits and
+// fits in a bcrypt hash. Its minimum length is checked by the binding tags.
+func StrongPassword(password string) bool {
+	if len(password) > maxPasswordBytes {
+		return false
+	}
+	var letter, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return letter && digit
+}
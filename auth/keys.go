@@ -0,0 +1,193 @@
+// Package auth issues and verifies the JWTs of the API. Tokens are signed
+// with the active key and carry its ID in the kid header, so keys can be
+// rotated while tokens signed with the previous ones stay valid until they
+// expire or the old key is removed from the configuration.
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// minSecretLength is the shortest HMAC secret accepted, 256 bits.
+const minSecretLength = 32
+
+// minRSABits is the smallest RSA modulus accepted.
+const minRSABits = 2048
+
+// defaultKeyID is the kid of the key configured with JWT_SECRET.
+const defaultKeyID = "default"
+
+// Key is a signing key identified by its kid.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// signing is passed to Method.Sign and verifying to Method.Verify.
+	signing   interface{}
+	verifying interface{}
+}
+
+// NewHMACKey returns an HS256 key.
+func NewHMACKey(id string, secret []byte) (Key, error) {
+	if len(secret) < minSecretLength {
+		return Key{}, fmt.Errorf("secret of key %q is shorter than %d bytes", id, minSecretLength)
+	}
+	return Key{ID: id, Method: jwt.SigningMethodHS256, signing: secret, verifying: secret}, nil
+}
+
+// NewRSAKey returns an RS256 key.
+func NewRSAKey(id string, private *rsa.PrivateKey) (Key, error) {
+	if private.N.BitLen() < minRSABits {
+		return Key{}, fmt.Errorf("RSA key %q is shorter than %d bits", id, minRSABits)
+	}
+	return Key{ID: id, Method: jwt.SigningMethodRS256, signing: private, verifying: &private.PublicKey}, nil
+}
+
+// NewEd25519Key returns an EdDSA key.
+func NewEd25519Key(id string, private ed25519.PrivateKey) Key {
+	return Key{ID: id, Method: SigningMethodEdDSA, signing: private, verifying: private.Public().(ed25519.PublicKey)}
+}
+
+// ParsePrivateKey reads a PEM encoded RSA (PKCS #1 or #8) or Ed25519 (PKCS
+// #8) private key and returns the matching RS256 or EdDSA key.
+func ParsePrivateKey(id string, data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, fmt.Errorf("key %q is not PEM encoded", id)
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return Key{}, fmt.Errorf("error parsing key %q: %v", id, err)
+		}
+		return NewRSAKey(id, private)
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return Key{}, fmt.Errorf("error parsing key %q: %v", id, err)
+	}
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		return NewRSAKey(id, private)
+	case ed25519.PrivateKey:
+		return NewEd25519Key(id, private), nil
+	}
+	return Key{}, fmt.Errorf("key %q is neither RSA nor Ed25519", id)
+}
+
+// keys holds the configured keys and the ID of the one that signs new tokens.
+var keys = struct {
+	sync.RWMutex
+	byID   map[string]Key
+	active string
+}{}
+
+// Configure replaces the keys. activeID selects the signing key and must be
+// one of them.
+func Configure(activeID string, list ...Key) error {
+	byID := make(map[string]Key, len(list))
+	for _, key := range list {
+		if key.ID == "" {
+			return errors.New("key without kid")
+		}
+		if _, duplicate := byID[key.ID]; duplicate {
+			return fmt.Errorf("duplicate kid %q", key.ID)
+		}
+		byID[key.ID] = key
+	}
+	if _, ok := byID[activeID]; !ok {
+		return fmt.Errorf("active kid %q is not configured", activeID)
+	}
+
+	keys.Lock()
+	keys.byID = byID
+	keys.active = activeID
+	keys.Unlock()
+	return nil
+}
+
+// Load configures the keys from the environment:
+//
+//	JWT_KEYS=2024-06:<secret>,2024-01:<old secret>
+//	JWT_KEY_FILES=rsa-2024:/etc/mrt-api/rsa.pem,ed-2024:/etc/mrt-api/ed25519.pem
+//	JWT_ACTIVE_KID=2024-06
+//
+// JWT_KEYS holds HS256 secrets, which must be at least 32 bytes long, and
+// JWT_KEY_FILES the PEM files of RS256 or EdDSA private keys. A single
+// JWT_SECRET may be given instead of JWT_KEYS. JWT_ACTIVE_KID defaults to the
+// first key, from JWT_KEYS before JWT_KEY_FILES.
+func Load() error {
+	var list []Key
+	if value := os.Getenv("JWT_KEYS"); value != "" {
+		for _, entry := range strings.Split(value, ",") {
+			id, secret, found := strings.Cut(strings.TrimSpace(entry), ":")
+			if !found {
+				return fmt.Errorf("JWT_KEYS entry %q is not kid:secret", entry)
+			}
+			key, err := NewHMACKey(id, []byte(secret))
+			if err != nil {
+				return err
+			}
+			list = append(list, key)
+		}
+	} else if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		key, err := NewHMACKey(defaultKeyID, []byte(secret))
+		if err != nil {
+			return err
+		}
+		list = append(list, key)
+	}
+	if value := os.Getenv("JWT_KEY_FILES"); value != "" {
+		for _, entry := range strings.Split(value, ",") {
+			id, path, found := strings.Cut(strings.TrimSpace(entry), ":")
+			if !found {
+				return fmt.Errorf("JWT_KEY_FILES entry %q is not kid:path", entry)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("error reading key %q: %v", id, err)
+			}
+			key, err := ParsePrivateKey(id, data)
+			if err != nil {
+				return err
+			}
+			list = append(list, key)
+		}
+	}
+	if len(list) == 0 {
+		return errors.New("no signing key configured, set JWT_SECRET, JWT_KEYS or JWT_KEY_FILES")
+	}
+
+	activeID := os.Getenv("JWT_ACTIVE_KID")
+	if activeID == "" {
+		activeID = list[0].ID
+	}
+	return Configure(activeID, list...)
+}
+
+func activeKey() (Key, error) {
+	keys.RLock()
+	defer keys.RUnlock()
+	key, ok := keys.byID[keys.active]
+	if !ok {
+		return Key{}, errors.New("no signing key configured")
+	}
+	return key, nil
+}
+
+func keyByID(id string) (Key, bool) {
+	keys.RLock()
+	defer keys.RUnlock()
+	key, ok := keys.byID[id]
+	return key, ok
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PasswordResetTTL is how long a password reset token can be used.
+const PasswordResetTTL = time.Hour
+
+// ErrInvalidResetToken is returned for unknown, expired and already used
+// password reset tokens.
+var ErrInvalidResetToken = errors.New("invalid password reset token")
+
+// SetPassword replaces the password hash of a user. Every session of the
+// user ends, access tokens issued before the change are refused and pending
+// reset tokens stop working. It returns the new token generation of the
+// user, which new access tokens must carry, or sql.ErrNoRows when the user
+// does not exist.
+func SetPassword(db *sql.DB, userID int, hash string) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	generation, err := setPassword(tx, userID, hash)
+	if err != nil {
+		return 0, err
+	}
+	return generation, tx.Commit()
+}
+
+// CreatePasswordReset returns a new reset token for a user, replacing the
+// ones not used yet. Only the hash of the token is stored.
+func CreatePasswordReset(db *sql.DB, userID int) (string, error) {
+	token, err := randomString(32)
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE password_resets SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL", userID); err != nil {
+		return "", fmt.Errorf("error replacing password reset tokens: %v", err)
+	}
+	_, err = tx.Exec("INSERT INTO password_resets (user_id, token_hash, expires_at) VALUES ($1, $2, $3)",
+		userID, hashToken(token), time.Now().Add(PasswordResetTTL))
+	if err != nil {
+		return "", fmt.Errorf("error storing password reset token: %v", err)
+	}
+	return token, tx.Commit()
+}
+
+// ResetPassword uses up a reset token and sets the password hash of its
+// user, with the effects of SetPassword. Tokens of disabled accounts are
+// refused. It returns the ID of the user.
+func ResetPassword(db *sql.DB, token string, hash string) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRow(`
+		UPDATE password_resets SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+			AND user_id IN (SELECT id FROM users WHERE disabled_at IS NULL)
+		RETURNING user_id`, hashToken(token)).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrInvalidResetToken
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error using password reset token: %v", err)
+	}
+
+	if _, err := setPassword(tx, userID, hash); err != nil {
+		return 0, err
+	}
+	return userID, tx.Commit()
+}
+
+func setPassword(tx *sql.Tx, userID int, hash string) (int, error) {
+	var generation int
+	err := tx.QueryRow("UPDATE users SET password = $2, token_generation = token_generation + 1 WHERE id = $1 RETURNING token_generation",
+		userID, hash).Scan(&generation)
+	if err == sql.ErrNoRows {
+		return 0, err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error updating password: %v", err)
+	}
+	if _, err := tx.Exec("UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID); err != nil {
+		return 0, fmt.Errorf("error revoking sessions: %v", err)
+	}
+	if _, err := tx.Exec("UPDATE password_resets SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL", userID); err != nil {
+		return 0, fmt.Errorf("error invalidating password reset tokens: %v", err)
+	}
+	return generation, nil
+}
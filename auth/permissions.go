@@ -0,0 +1,76 @@
+package auth
+
+// Roles of users.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Permissions guard the admin routes. Routes require a permission rather
+// than a role so that roles can be split up without touching the routes.
+//
+// PermSchedulesWrite and PermReviewsModerate are granted to admins ahead of
+// the schedule editing and review moderation endpoints that will require
+// them.
+const (
+	PermSchedulesWrite  = "schedules:write"
+	PermReviewsModerate = "reviews:moderate"
+	PermUsersManage     = "users:manage"
+	PermAlertsManage    = "alerts:manage"
+	PermWebhooksManage  = "webhooks:manage"
+	PermAPIKeysManage   = "api_keys:manage"
+	PermOperationsRead  = "operations:read"
+)
+
+// rolePermissions lists what each role may do. Roles missing from the map,
+// and users without a role, have no permissions.
+var rolePermissions = map[string][]string{
+	RoleUser: {},
+	RoleAdmin: {
+		PermSchedulesWrite,
+		PermReviewsModerate,
+		PermUsersManage,
+		PermAlertsManage,
+		PermWebhooksManage,
+		PermAPIKeysManage,
+		PermOperationsRead,
+	},
+}
+
+// Roles returns the known roles.
+func Roles() []string {
+	return []string{RoleUser, RoleAdmin}
+}
+
+// IsRole reports whether role is a known role.
+func IsRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// StaffRoles returns the roles granted at least one permission, which are
+// the roles allowed into the admin routes.
+func StaffRoles() []string {
+	var roles []string
+	for _, role := range Roles() {
+		if len(rolePermissions[role]) > 0 {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// Permissions returns the permissions granted to a role.
+func Permissions(role string) []string {
+	return rolePermissions[role]
+}
+
+// HasPermission reports whether a role grants a permission.
+func HasPermission(role string, permission string) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"web-scrapper/models"
+)
+
+// refreshTokenTTL is how long a refresh token can be used. Every use
+// replaces it with a new one valid as long again.
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+// ErrInvalidRefreshToken is returned for unknown, expired and revoked
+// refresh tokens.
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// IssueRefreshToken starts a new session for a user and returns its refresh
+// token. Only the hash of the token is stored.
+func IssueRefreshToken(db *sql.DB, userID int) (string, error) {
+	family, err := randomString(16)
+	if err != nil {
+		return "", err
+	}
+	return insertRefreshToken(db, userID, family)
+}
+
+// RotateRefreshToken revokes a refresh token and returns its user with a new
+// token of the same session. Presenting a token that was already rotated
+// means it leaked, so the whole session is revoked.
+func RotateRefreshToken(db *sql.DB, token string) (int, string, error) {
+	var userID int
+	var family string
+	err := db.QueryRow(`
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING user_id, family_id`, hashToken(token)).Scan(&userID, &family)
+	if err == sql.ErrNoRows {
+		revokeReusedFamily(db, token)
+		return 0, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("error rotating refresh token: %v", err)
+	}
+
+	next, err := insertRefreshToken(db, userID, family)
+	return userID, next, err
+}
+
+// RevokeRefreshToken ends the session of a refresh token of the given user.
+// Unknown tokens and tokens of other users are ignored.
+func RevokeRefreshToken(db *sql.DB, token string, userID int) error {
+	_, err := db.Exec(`
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash = $1 AND user_id = $2) AND revoked_at IS NULL`,
+		hashToken(token), userID)
+	if err != nil {
+		return fmt.Errorf("error revoking refresh token: %v", err)
+	}
+	return nil
+}
+
+// RevokeUserSessions ends every session of a user.
+func RevokeUserSessions(db *sql.DB, userID int) error {
+	_, err := db.Exec("UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	if err != nil {
+		return fmt.Errorf("error revoking sessions: %v", err)
+	}
+	return nil
+}
+
+// RevokeAccessToken rejects an access token until it expires.
+func RevokeAccessToken(db *sql.DB, claims *models.Claims) error {
+	_, err := db.Exec("INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING",
+		claims.Id, time.Unix(claims.ExpiresAt, 0))
+	if err != nil {
+		return fmt.Errorf("error revoking access token: %v", err)
+	}
+	return nil
+}
+
+// IsRevoked reports whether the access token with the given jti was revoked.
+func IsRevoked(db *sql.DB, jti string) (bool, error) {
+	var revoked bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = $1)", jti).Scan(&revoked)
+	return revoked, err
+}
+
+// PurgeExpired deletes the refresh tokens, revoked access tokens and
+// password reset tokens that have expired and can no longer be presented.
+func PurgeExpired(db *sql.DB) error {
+	if _, err := db.Exec("DELETE FROM refresh_tokens WHERE expires_at < NOW()"); err != nil {
+		return fmt.Errorf("error purging refresh tokens: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM revoked_tokens WHERE expires_at < NOW()"); err != nil {
+		return fmt.Errorf("error purging revoked tokens: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM password_resets WHERE expires_at < NOW()"); err != nil {
+		return fmt.Errorf("error purging password reset tokens: %v", err)
+	}
+	return nil
+}
+
+func insertRefreshToken(db *sql.DB, userID int, family string) (string, error) {
+	token, err := randomString(32)
+	if err != nil {
+		return "", err
+	}
+	_, err = db.Exec("INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at) VALUES ($1, $2, $3, $4)",
+		userID, hashToken(token), family, time.Now().Add(refreshTokenTTL))
+	if err != nil {
+		return "", fmt.Errorf("error storing refresh token: %v", err)
+	}
+	return token, nil
+}
+
+// revokeReusedFamily revokes the session of a token that was already
+// revoked, as only a stolen copy would be presented again.
+func revokeReusedFamily(db *sql.DB, token string) {
+	result, err := db.Exec(`
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash = $1 AND revoked_at IS NOT NULL)
+			AND revoked_at IS NULL`, hashToken(token))
+	if err != nil {
+		log.Printf("Error revoking reused refresh token family: %v", err)
+		return
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected > 0 {
+		log.Println("Refresh token reused, session revoked")
+	}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString returns n random bytes encoded for use in URLs and headers.
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating token: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
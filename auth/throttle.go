@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle allows a number of attempts per key in fixed windows. It lives in
+// the memory of one replica, so with several replicas each one allows the
+// limit on its own.
+type Throttle struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	windows map[string]*throttleWindow
+	swept   time.Time
+}
+
+type throttleWindow struct {
+	attempts int
+	ends     time.Time
+}
+
+// NewThrottle returns a throttle allowing limit attempts per key every window.
+func NewThrottle(limit int, window time.Duration) *Throttle {
+	return &Throttle{limit: limit, window: window, windows: make(map[string]*throttleWindow)}
+}
+
+// Allow counts an attempt for key. When the limit is already reached it
+// returns false and how long until the next attempt is allowed.
+func (t *Throttle) Allow(key string) (bool, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.swept) >= t.window {
+		for k, w := range t.windows {
+			if !now.Before(w.ends) {
+				delete(t.windows, k)
+			}
+		}
+		t.swept = now
+	}
+
+	w, ok := t.windows[key]
+	if !ok || !now.Before(w.ends) {
+		w = &throttleWindow{ends: now.Add(t.window)}
+		t.windows[key] = w
+	}
+	if w.attempts >= t.limit {
+		return false, w.ends.Sub(now)
+	}
+	w.attempts++
+	return true, 0
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"time"
+
+	"web-scrapper/models"
+)
+
+// TicketTTL is how long a WebSocket ticket can be used to connect.
+const TicketTTL = 30 * time.Second
+
+// ticketAudience keeps tickets and access tokens from standing in for each
+// other.
+const ticketAudience = "ws"
+
+// IssueTicket signs a WebSocket ticket for the user of an access token.
+// Browsers cannot set headers on WebSocket requests, so they exchange their
+// access token for a ticket and pass it in the URL. The short lifetime
+// limits what a ticket leaked through logs is worth; the caller also revokes
+// it on first use.
+func IssueTicket(claims *models.Claims) (string, time.Time, error) {
+	return sign(models.Claims{Username: claims.Username, UserID: claims.UserID, Role: claims.Role, Generation: claims.Generation}, ticketAudience, TicketTTL)
+}
+
+// VerifyTicket checks a WebSocket ticket like Verify checks access tokens.
+func VerifyTicket(ticket string) (*models.Claims, error) {
+	return verify(ticket, ticketAudience)
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"web-scrapper/models"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// AccessTokenTTL is the lifetime of access tokens. Clients renew them with
+// their refresh token.
+const AccessTokenTTL = 15 * time.Minute
+
+// ErrInvalidToken is returned for every token that cannot be trusted,
+// whatever the reason.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Issue signs an access token for a user with the active key and returns it
+// with its expiry. Each token gets a unique jti so it can be revoked.
+func Issue(user models.User) (string, time.Time, error) {
+	return sign(models.Claims{Username: user.Username, UserID: user.ID, Role: user.Role, Generation: user.TokenGeneration}, "", AccessTokenTTL)
+}
+
+// Verify checks the signature and expiry of an access token and returns its
+// claims. The key is chosen by the kid header and the token must use that
+// key's algorithm. Tokens without a jti or expiry, which could never be
+// revoked, are rejected, as are tokens meant for another audience such as
+// WebSocket tickets. Whether the jti was revoked is checked with IsRevoked.
+func Verify(tokenString string) (*models.Claims, error) {
+	return verify(tokenString, "")
+}
+
+// sign issues a token with the claims of a user for the given audience,
+// empty for access tokens, valid for ttl.
+func sign(claims models.Claims, audience string, ttl time.Duration) (string, time.Time, error) {
+	key, err := activeKey()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	jti, err := randomString(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims.StandardClaims = jwt.StandardClaims{
+		Id:        jti,
+		Audience:  audience,
+		ExpiresAt: expiresAt.Unix(),
+		IssuedAt:  now.Unix(),
+	}
+
+	token := jwt.NewWithClaims(key.Method, &claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.signing)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error signing token: %v", err)
+	}
+	return signed, expiresAt, nil
+}
+
+func verify(tokenString string, audience string) (*models.Claims, error) {
+	claims := &models.Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keyByID(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown kid %q", kid)
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.verifying, nil
+	})
+	if err != nil || !token.Valid || claims.Id == "" || claims.ExpiresAt == 0 || claims.Audience != audience {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTTL is how long entries live when nothing purges them earlier.
+const DefaultTTL = 6 * time.Hour
+
+// Backend stores encoded values under fully qualified keys.
+type Backend interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+	// Purge removes every entry stored by this API.
+	Purge() error
+}
+
+// Broadcaster is implemented by backends shared between replicas. Each purge
+// is announced so that the other replicas can refresh their own state.
+type Broadcaster interface {
+	Announce() error
+	Listen(onPurge func())
+}
+
+var (
+	backendMu sync.RWMutex
+	backend   Backend = NewMemory()
+
+	hooksMu     sync.Mutex
+	remoteHooks []func()
+)
+
+// SetBackend replaces the backend used by every cache. It is meant to be
+// called once at startup, before requests are served.
+func SetBackend(b Backend) {
+	backendMu.Lock()
+	defer backendMu.Unlock()
+	backend = b
+
+	if broadcaster, ok := b.(Broadcaster); ok {
+		go broadcaster.Listen(runRemoteHooks)
+	}
+}
+
+// OnRemotePurge registers fn to run whenever another replica purges the
+// shared cache, typically to reload state derived from the timetable.
+func OnRemotePurge(fn func()) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	remoteHooks = append(remoteHooks, fn)
+}
+
+func runRemoteHooks() {
+	hooksMu.Lock()
+	hooks := append([]func(){}, remoteHooks...)
+	hooksMu.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+func currentBackend() Backend {
+	backendMu.RLock()
+	defer backendMu.RUnlock()
+	return backend
+}
+
+// Cache is a typed view of the backend whose keys live in one namespace, so
+// caches storing different shapes can never collide.
+type Cache[T any] struct {
+	namespace string
+	ttl       time.Duration
+	stats     *counters
+}
+
+// New returns the cache of a namespace. Namespaces must be unique.
+func New[T any](namespace string) *Cache[T] {
+	return &Cache[T]{namespace: namespace, ttl: DefaultTTL, stats: register(namespace)}
+}
+
+// Get returns the value cached under key and whether it was found.
+func (c *Cache[T]) Get(key string) (T, bool) {
+	var value T
+	raw, found := currentBackend().Get(c.key(key))
+	if !found {
+		c.stats.misses.Add(1)
+		return value, false
+	}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		log.Printf("Error decoding cache entry %s: %v", c.key(key), err)
+		c.stats.misses.Add(1)
+		return value, false
+	}
+	c.stats.hits.Add(1)
+	return value, true
+}
+
+// Set caches value under key for the namespace TTL.
+func (c *Cache[T]) Set(key string, value T) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Error encoding cache entry %s: %v", c.key(key), err)
+		return
+	}
+	currentBackend().Set(c.key(key), raw, c.ttl)
+}
+
+func (c *Cache[T]) key(key string) string {
+	return c.namespace + ":" + key
+}
+
+// Purge drops every cached entry. runScrapingTask calls it once a new
+// timetable has been swapped in so no stale data is served. Shared backends
+// also tell the other replicas about it.
+func Purge() error {
+	b := currentBackend()
+	if err := b.Purge(); err != nil {
+		return err
+	}
+	log.Println("Cache purged")
+
+	if broadcaster, ok := b.(Broadcaster); ok {
+		if err := broadcaster.Announce(); err != nil {
+			return fmt.Errorf("error announcing cache purge: %v", err)
+		}
+	}
+	return nil
+}
+
+// Stats holds the hit and miss counts of a namespace since startup.
+type Stats struct {
+	Namespace string  `json:"namespace"`
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	HitRatio  float64 `json:"hit_ratio"`
+}
+
+type counters struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+var (
+	statsMu sync.Mutex
+	stats   = make(map[string]*counters)
+)
+
+func register(namespace string) *counters {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	if _, exists := stats[namespace]; exists {
+		panic("cache: duplicate namespace " + namespace)
+	}
+	stats[namespace] = &counters{}
+	return stats[namespace]
+}
+
+// AllStats returns the hit and miss counts of every namespace.
+func AllStats() []Stats {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	all := make([]Stats, 0, len(stats))
+	for namespace, counter := range stats {
+		entry := Stats{Namespace: namespace, Hits: counter.hits.Load(), Misses: counter.misses.Load()}
+		if total := entry.Hits + entry.Misses; total > 0 {
+			entry.HitRatio = float64(entry.Hits) / float64(total)
+		}
+		all = append(all, entry)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Namespace < all[j].Namespace })
+	return all
+}
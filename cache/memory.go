@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"time"
+
+	gocache "github.com/patrickmn/go-cache"
+)
+
+// Memory is an in-process backend built on go-cache.
+type Memory struct {
+	store *gocache.Cache
+}
+
+// NewMemory returns an empty in-process backend.
+func NewMemory() *Memory {
+	return &Memory{store: gocache.New(DefaultTTL, 10*time.Minute)}
+}
+
+func (m *Memory) Get(key string) ([]byte, bool) {
+	value, found := m.store.Get(key)
+	if !found {
+		return nil, false
+	}
+	raw, ok := value.([]byte)
+	return raw, ok
+}
+
+func (m *Memory) Set(key string, value []byte, ttl time.Duration) {
+	m.store.Set(key, value, ttl)
+}
+
+func (m *Memory) Purge() error {
+	m.store.Flush()
+	return nil
+}
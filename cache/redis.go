@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisKeyPrefix        = "mrt-api:cache:"
+	redisPurgeChannel     = "mrt-api:cache:purge"
+	redisOperationTimeout = 2 * time.Second
+)
+
+// Redis is a backend shared by every replica. Purges are announced on a
+// pub/sub channel so the other replicas can drop their local state as well.
+type Redis struct {
+	client   *redis.Client
+	instance string
+}
+
+// NewRedis connects to the Redis server at url, e.g.
+// redis://:password@localhost:6379/0.
+func NewRedis(url string) (*Redis, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(options)
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisOperationTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	instance := make([]byte, 8)
+	if _, err := rand.Read(instance); err != nil {
+		client.Close()
+		return nil, err
+	}
+	log.Println("Redis cache connection established")
+	return &Redis{client: client, instance: hex.EncodeToString(instance)}, nil
+}
+
+func (r *Redis) Get(key string) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOperationTimeout)
+	defer cancel()
+
+	value, err := r.client.Get(ctx, redisKeyPrefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("Error reading cache entry %s from Redis: %v", key, err)
+		}
+		return nil, false
+	}
+	return value, true
+}
+
+func (r *Redis) Set(key string, value []byte, ttl time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOperationTimeout)
+	defer cancel()
+
+	if err := r.client.Set(ctx, redisKeyPrefix+key, value, ttl).Err(); err != nil {
+		log.Printf("Error writing cache entry %s to Redis: %v", key, err)
+	}
+}
+
+// Purge deletes every key of this API, leaving other data in the same
+// database alone. The keys are all collected before any is deleted, since
+// deleting during a SCAN may move the cursor past keys not yet returned.
+func (r *Redis) Purge() error {
+	ctx := context.Background()
+	iter := r.client.Scan(ctx, 0, redisKeyPrefix+"*", 500).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	for len(keys) > 0 {
+		batch := keys[:min(len(keys), 500)]
+		if err := r.client.Unlink(ctx, batch...).Err(); err != nil {
+			return err
+		}
+		keys = keys[len(batch):]
+	}
+	return nil
+}
+
+// Announce tells the other replicas that the cache has been purged.
+func (r *Redis) Announce() error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOperationTimeout)
+	defer cancel()
+	return r.client.Publish(ctx, redisPurgeChannel, r.instance).Err()
+}
+
+// Close disconnects from Redis, which also ends Listen.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+// Listen calls onPurge for every purge announced by another replica. It
+// blocks for the lifetime of the process; the subscription reconnects on its
+// own when the connection drops.
+func (r *Redis) Listen(onPurge func()) {
+	subscription := r.client.Subscribe(context.Background(), redisPurgeChannel)
+	defer subscription.Close()
+
+	for message := range subscription.Channel() {
+		if message.Payload == r.instance {
+			continue
+		}
+		log.Println("Cache purged by another replica")
+		onPurge()
+	}
+}
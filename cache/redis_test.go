@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// The namespaces of the tests are registered once, since registering one
+// twice panics.
+var (
+	stationsTestCache  = New[string]("test:stations")
+	schedulesTestCache = New[string]("test:schedules")
+)
+
+var (
+	remotePurgeOnce sync.Once
+	remotePurges    = make(chan struct{}, 16)
+)
+
+func newTestRedis(t *testing.T, server *miniredis.Miniredis) *Redis {
+	t.Helper()
+	r, err := NewRedis("redis://" + server.Addr())
+	if err != nil {
+		t.Fatalf("NewRedis: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+// useBackend installs b for the duration of the test.
+func useBackend(t *testing.T, b Backend) {
+	SetBackend(b)
+	t.Cleanup(func() { SetBackend(NewMemory()) })
+}
+
+func TestRedisGetSet(t *testing.T) {
+	server := miniredis.RunT(t)
+	r := newTestRedis(t, server)
+
+	if _, found := r.Get("missing"); found {
+		t.Error("Get of a missing key found a value")
+	}
+
+	r.Set("stations:all", []byte("payload"), time.Minute)
+	value, found := r.Get("stations:all")
+	if !found || string(value) != "payload" {
+		t.Errorf("Get = %q, %v; want payload, true", value, found)
+	}
+	if ttl := server.TTL(redisKeyPrefix + "stations:all"); ttl != time.Minute {
+		t.Errorf("TTL = %v, want %v", ttl, time.Minute)
+	}
+
+	server.FastForward(2 * time.Minute)
+	if _, found := r.Get("stations:all"); found {
+		t.Error("Get found an expired value")
+	}
+}
+
+func TestRedisNamespaces(t *testing.T) {
+	server := miniredis.RunT(t)
+	useBackend(t, newTestRedis(t, server))
+
+	stationsTestCache.Set("1", "stations")
+	schedulesTestCache.Set("1", "schedules")
+
+	if value, found := stationsTestCache.Get("1"); !found || value != "stations" {
+		t.Errorf("stations Get = %q, %v; want stations, true", value, found)
+	}
+	if value, found := schedulesTestCache.Get("1"); !found || value != "schedules" {
+		t.Errorf("schedules Get = %q, %v; want schedules, true", value, found)
+	}
+	if !server.Exists(redisKeyPrefix+"test:stations:1") || !server.Exists(redisKeyPrefix+"test:schedules:1") {
+		t.Errorf("keys = %v, want one per namespace", server.Keys())
+	}
+}
+
+func TestRedisPurge(t *testing.T) {
+	server := miniredis.RunT(t)
+	r := newTestRedis(t, server)
+
+	// More keys than one SCAN batch, next to data of another application.
+	for i := 0; i < 1200; i++ {
+		r.Set(fmt.Sprintf("schedules:%d", i), []byte("x"), time.Hour)
+	}
+	server.Set("other-app:key", "kept")
+
+	if err := r.Purge(); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	keys := server.Keys()
+	if len(keys) != 1 || keys[0] != "other-app:key" {
+		t.Errorf("keys after Purge = %d keys (%v...), want only other-app:key", len(keys), keys[:min(len(keys), 3)])
+	}
+}
+
+func TestRedisRemotePurge(t *testing.T) {
+	server := miniredis.RunT(t)
+	local := newTestRedis(t, server)
+	remote := newTestRedis(t, server)
+
+	remotePurgeOnce.Do(func() {
+		OnRemotePurge(func() { remotePurges <- struct{}{} })
+	})
+	for len(remotePurges) > 0 {
+		<-remotePurges
+	}
+	useBackend(t, local)
+
+	// Wait for the subscription started by SetBackend.
+	deadline := time.Now().Add(2 * time.Second)
+	for server.PubSubNumSub(redisPurgeChannel)[redisPurgeChannel] == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("SetBackend did not subscribe to purges")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A replica ignores its own announcements.
+	if err := local.Announce(); err != nil {
+		t.Fatalf("Announce: %v", err)
+	}
+	if err := remote.Announce(); err != nil {
+		t.Fatalf("Announce: %v", err)
+	}
+
+	select {
+	case <-remotePurges:
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnRemotePurge hook not called for a purge of another replica")
+	}
+	select {
+	case <-remotePurges:
+		t.Error("OnRemotePurge hook called for the replica's own purge")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"web-scrapper/alerts"
+	"web-scrapper/database"
+	"web-scrapper/models"
+	"web-scrapper/response"
+	"web-scrapper/timetable"
+
+	"github.com/gin-gonic/gin"
+)
+
+// attachAlerts adds the active alerts affecting a station, and one direction
+// when arah is set, to the response envelope. A zero stationID attaches every
+// active alert.
+func attachAlerts(c *gin.Context, stationID int, arah string) {
+	now := time.Now()
+	active := alerts.Active(now)
+	if stationID != 0 {
+		active = alerts.ForStation(now, stationID, arah)
+	}
+	response.SetAlerts(c, alerts.Localize(active, response.Lang(c)))
+}
+
+// GetActiveAlerts lists the alerts in effect now, optionally only those
+// affecting ?station_id= and ?arah=.
+func GetActiveAlerts(c *gin.Context) {
+	now := time.Now()
+	active := alerts.Active(now)
+	if value := c.Query("station_id"); value != "" {
+		stationID, err := strconv.Atoi(value)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "station_id")
+			return
+		}
+		active = alerts.ForStation(now, stationID, c.Query("arah"))
+	}
+	response.Success(c, http.StatusOK, response.MsgAlertsFetched, alerts.Localize(active, response.Lang(c)))
+}
+
+// ListAlerts lists every alert, ended ones included, for admins.
+func ListAlerts(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	list, total, err := alerts.List(database.GetDB(), page.Limit, page.Offset)
+	if err != nil {
+		log.Printf("Error fetching alerts: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	response.SuccessWithMeta(c, http.StatusOK, response.MsgAlertsFetched, alerts.Localize(list, response.Lang(c)), page.meta(total))
+}
+
+func GetAlert(c *gin.Context) {
+	id, ok := parseAlertID(c)
+	if !ok {
+		return
+	}
+
+	alert, err := alerts.Get(database.GetDB(), id)
+	if err != nil {
+		alertError(c, err, "Error fetching alert")
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgAlertFetched, alerts.Localize([]models.ServiceAlert{alert}, response.Lang(c))[0])
+}
+
+func CreateAlert(c *gin.Context) {
+	input, ok := bindAlertInput(c)
+	if !ok {
+		return
+	}
+
+	alert, err := alerts.Create(database.GetDB(), input, c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error creating alert: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	response.Success(c, http.StatusCreated, response.MsgAlertCreated, alerts.Localize([]models.ServiceAlert{alert}, response.Lang(c))[0])
+}
+
+func UpdateAlert(c *gin.Context) {
+	id, ok := parseAlertID(c)
+	if !ok {
+		return
+	}
+	input, ok := bindAlertInput(c)
+	if !ok {
+		return
+	}
+
+	alert, err := alerts.Update(database.GetDB(), id, input)
+	if err != nil {
+		alertError(c, err, "Error updating alert")
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgAlertUpdated, alerts.Localize([]models.ServiceAlert{alert}, response.Lang(c))[0])
+}
+
+func DeleteAlert(c *gin.Context) {
+	id, ok := parseAlertID(c)
+	if !ok {
+		return
+	}
+
+	if err := alerts.Delete(database.GetDB(), id); err != nil {
+		alertError(c, err, "Error deleting alert")
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgAlertDeleted, nil, strconv.Itoa(id))
+}
+
+// bindAlertInput binds and checks an alert body. It writes the error
+// response itself and reports false when that happened.
+func bindAlertInput(c *gin.Context) (models.ServiceAlertInput, bool) {
+	var input models.ServiceAlertInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BindError(c, err)
+		return input, false
+	}
+
+	startsAt := time.Now()
+	if input.StartsAt != nil {
+		startsAt = *input.StartsAt
+	}
+	if input.EndsAt != nil && !input.EndsAt.After(startsAt) {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "ends_at")
+		return input, false
+	}
+
+	index := timetable.CurrentIndex()
+	for _, stationID := range input.StationIDs {
+		if _, found := index.Station(stationID); !found {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "station_ids")
+			return input, false
+		}
+	}
+	return input, true
+}
+
+// parseAlertID reads the :id path parameter. It writes the error response
+// itself and reports false when that happened.
+func parseAlertID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "id")
+		return 0, false
+	}
+	return id, true
+}
+
+// alertError answers 404 for a missing alert and 500 for anything else.
+func alertError(c *gin.Context, err error, context string) {
+	if err == sql.ErrNoRows {
+		response.Error(c, http.StatusNotFound, response.CodeAlertNotFound, c.Param("id"))
+		return
+	}
+	log.Printf("%s: %v", context, err)
+	response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+}
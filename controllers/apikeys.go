@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"web-scrapper/auth"
+	"web-scrapper/database"
+	"web-scrapper/models"
+	"web-scrapper/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ListAPIKeys(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	keys, total, err := auth.ListAPIKeys(database.GetDB(), page.Limit, page.Offset)
+	if err != nil {
+		log.Printf("Error fetching API keys: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	response.SuccessWithMeta(c, http.StatusOK, response.MsgAPIKeysFetched, keys, page.meta(total))
+}
+
+// CreateAPIKey issues an API key for a machine client. The response is the
+// only place the key is shown.
+func CreateAPIKey(c *gin.Context) {
+	var input models.APIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	key, err := auth.CreateAPIKey(database.GetDB(), input, c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error creating API key: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	response.Success(c, http.StatusCreated, response.MsgAPIKeyCreated, key)
+}
+
+func RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "id")
+		return
+	}
+
+	key, err := auth.RevokeAPIKey(database.GetDB(), id)
+	if err == sql.ErrNoRows {
+		response.Error(c, http.StatusNotFound, response.CodeAPIKeyNotFound, c.Param("id"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error revoking API key: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgAPIKeyRevoked, key, strconv.Itoa(id))
+}
@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	"net/http"
+
+	"web-scrapper/cache"
+	"web-scrapper/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCacheStats returns the hit and miss counts of every cache namespace.
+func GetCacheStats(c *gin.Context) {
+	response.Success(c, http.StatusOK, response.MsgCacheStatsFetched, cache.AllStats())
+}
@@ -2,396 +2,371 @@ package controllers
 
 import (
 	"database/sql"
-	"fmt"
 	"log"
 	"net/http"
-	"strconv"
 	"time"
 
+	"web-scrapper/auth"
 	"web-scrapper/database"
 	"web-scrapper/models"
+	"web-scrapper/response"
+	"web-scrapper/timetable"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
-	"github.com/patrickmn/go-cache"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var jwtKey = []byte("your_secret_key")
+// uniqueViolation is the PostgreSQL error code of a unique constraint
+// violation.
+const uniqueViolation = "23505"
 
-var cacheInstance *cache.Cache
+// emailIndex is the unique index on the users' emails.
+const emailIndex = "users_email_idx"
 
-func init() {
-	cacheInstance = cache.New(6*time.Hour, 10*time.Minute)
-}
+// RegisterUser creates an account with the user role. A taken username or
+// email answers 409.
 func RegisterUser(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"data":    nil,
-			"message": "Data user dengan username " + user.Username + " telah disimpan",
-			"success": false,
-		})
+	var input models.RegisterInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BindError(c, err)
 		return
 	}
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(input.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Printf("Error hashing password: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
 		return
 	}
-	user.Password = string(hashedPassword)
-	query := `INSERT INTO users (username, password) VALUES ($1, $2) RETURNING id`
-	err = database.DB.QueryRow(query, user.Username, user.Password).Scan(&user.ID)
+
+	user := models.User{Username: input.Username, Email: input.Email, Role: auth.RoleUser}
+	query := `INSERT INTO users (username, email, password, role) VALUES ($1, NULLIF($2, ''), $3, $4) RETURNING id`
+	err = database.DB.QueryRow(query, user.Username, user.Email, hashedPassword, user.Role).Scan(&user.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "User registration failed"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"data":    nil,
-				"message": "Data employee dengan username " + user.Username + " telah disimpan",
-				"success": false,
-			})
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+			if pqErr.Constraint == emailIndex {
+				response.Error(c, http.StatusConflict, response.CodeEmailTaken)
+				return
+			}
+			response.Error(c, http.StatusConflict, response.CodeUsernameTaken, user.Username)
+			return
 		}
+		log.Printf("Error registering user: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeRegistrationFailed, user.Username)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"data":    user,
-		"message": "User berhasil registrasi",
-		"success": true,
-	})
+	response.Success(c, http.StatusOK, response.MsgRegisterSuccess, user)
 }
 
 func LoginUser(c *gin.Context) {
-	var loginDetails models.User
+	var loginDetails models.LoginInput
 	if err := c.ShouldBindJSON(&loginDetails); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		response.BindError(c, err)
 		return
 	}
 
 	var user models.User
-	query := `SELECT id, username, password, role FROM users WHERE username = $1`
-	err := database.DB.QueryRow(query, loginDetails.Username).Scan(&user.ID, &user.Username, &user.Password, &user.Role)
+	var disabled bool
+	query := `SELECT id, username, password, role, token_generation, disabled_at IS NOT NULL FROM users WHERE username = $1`
+	err := database.DB.QueryRow(query, loginDetails.Username).Scan(&user.ID, &user.Username, &user.Password, &user.Role, &user.TokenGeneration, &disabled)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"success": false,
-				"message": "Username atau Password salah"})
+			response.Error(c, http.StatusUnauthorized, response.CodeInvalidCredentials)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Printf("Error fetching user: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginDetails.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"message": "Username atau Password salah"})
+		response.Error(c, http.StatusUnauthorized, response.CodeInvalidCredentials)
 		return
 	}
-	var expirationTime time.Time
-
-	if user.Role == "admin" {
-		expirationTime = time.Now().Add(365 * 24 * time.Hour)
-	} else {
-		expirationTime = time.Now().Add(24 * time.Hour)
-	}
-	claims := &models.Claims{
-		Username: user.Username,
-		UserID:   user.ID,
-		Role:     user.Role,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
-		},
+	if disabled {
+		response.Error(c, http.StatusForbidden, response.CodeAccountDisabled)
+		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtKey)
+	refreshToken, err := auth.IssueRefreshToken(database.GetDB(), user.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "could not generate token"})
+		log.Printf("Error issuing refresh token: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"data":    tokenString,
-		"message": "login success",
-		"success": true,
-	})
+	pair, err := tokenPair(user, refreshToken)
+	if err != nil {
+		log.Printf("Error signing token: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgLoginSuccess, pair)
 }
 
-func CreateReview(c *gin.Context) {
-	db := database.GetDB()
-
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+// RefreshToken exchanges a refresh token for a new access token and a new
+// refresh token; the presented one cannot be used again.
+func RefreshToken(c *gin.Context) {
+	var request models.RefreshRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		response.BindError(c, err)
 		return
 	}
-
-	var review models.Review
-	if err := c.ShouldBindJSON(&review); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if request.RefreshToken == "" {
+		response.Error(c, http.StatusUnauthorized, response.CodeInvalidRefreshToken)
 		return
 	}
 
-	review.UserID = userID.(int)
+	userID, refreshToken, err := auth.RotateRefreshToken(database.GetDB(), request.RefreshToken)
+	if err == auth.ErrInvalidRefreshToken {
+		response.Error(c, http.StatusUnauthorized, response.CodeInvalidRefreshToken)
+		return
+	}
+	if err != nil {
+		log.Printf("Error refreshing token: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
 
-	var userExists bool
-	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)", review.UserID).Scan(&userExists)
+	// Read the user again so a changed role applies from the next token on.
+	var user models.User
+	var disabled bool
+	err = database.GetDB().QueryRow("SELECT id, username, role, token_generation, disabled_at IS NOT NULL FROM users WHERE id = $1", userID).
+		Scan(&user.ID, &user.Username, &user.Role, &user.TokenGeneration, &disabled)
+	if err == sql.ErrNoRows {
+		response.Error(c, http.StatusUnauthorized, response.CodeInvalidRefreshToken)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "error checking user existence"})
+		log.Printf("Error fetching user: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
 		return
 	}
-	if !userExists {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"succes":  false,
-			"message": "user tidak ditemukan"})
+	if disabled {
+		response.Error(c, http.StatusForbidden, response.CodeAccountDisabled)
 		return
 	}
 
-	query := `INSERT into reviews(user_id, rating, comment) VALUES ($1,$2,$3) RETURNING user_id, created_at`
-	err = db.QueryRow(query, review.UserID, review.Rating, review.Comment).Scan(&review.ID, &review.CreatedAt)
+	pair, err := tokenPair(user, refreshToken)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Printf("Error signing token: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{
-		"succes":  true,
-		"message": "review berhasil ditambahkan",
-		"data":    review})
+	response.Success(c, http.StatusOK, response.MsgTokenRefreshed, pair)
 }
 
-func GetAllReviews(c *gin.Context) {
-	db := database.GetDB()
-
-	var reviews []models.Review
-
-	query := `SELECT id, rating, comment, created_at FROM reviews  ORDER BY created_at DESC `
-	rows, err := db.Query(query)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+// Logout revokes the access token of the request and the session of the
+// refresh token in the body, or every session of the user with "all": true.
+func Logout(c *gin.Context) {
+	// API keys have no session; they are revoked by an admin.
+	value, exists := c.Get("claims")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, response.CodeUnauthorized)
 		return
 	}
-	defer rows.Close()
+	claims := value.(*models.Claims)
 
-	for rows.Next() {
-		var review models.Review
-		if err := rows.Scan(&review.ID, &review.Rating, &review.Comment, &review.CreatedAt); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var request models.RefreshRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			response.BindError(c, err)
 			return
 		}
-		reviews = append(reviews, review)
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"succes":  true,
-		"message": "berhasil mengambil seluruh data review",
-		"data":    reviews})
-}
 
-func GetAllSchedules(c *gin.Context) {
 	db := database.GetDB()
-	if db == nil {
-		log.Println("Database connection is nil")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-		return
-	}
-	cacheKey := "all_schedules"
-	cachedData, found := c.Get(cacheKey)
-	if found {
-		log.Println("fetching cached data")
-		c.Header("X-Data-Source", "Cache")
-		c.JSON(http.StatusOK, cachedData)
-		return
+	err := auth.RevokeAccessToken(db, claims)
+	if err == nil && request.All {
+		err = auth.RevokeUserSessions(db, claims.UserID)
+	} else if err == nil && request.RefreshToken != "" {
+		err = auth.RevokeRefreshToken(db, request.RefreshToken, claims.UserID)
 	}
-	rows, err := db.Query("SELECT id, station_id, stasiun_name, arah, to_char(jadwal, 'HH24:MI') as jadwal FROM schedules")
 	if err != nil {
-		log.Printf("Error fetching schedules: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching data"})
+		log.Printf("Error logging out: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
 		return
 	}
-	defer rows.Close()
+	response.Success(c, http.StatusOK, response.MsgLogoutSuccess, nil)
+}
 
-	var schedules []models.Schedule
-	for rows.Next() {
-		var schedule models.Schedule
-		if err := rows.Scan(&schedule.ID, &schedule.StasiunID, &schedule.StasiunName, &schedule.Arah, &schedule.Jadwal); err != nil {
-			log.Printf("Error scanning row: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing data"})
-			return
-		}
-		schedules = append(schedules, schedule)
+// IssueWebSocketTicket exchanges the access token of the request for a
+// short-lived ticket that browsers pass to /api/v1/ws in ?ticket=.
+func IssueWebSocketTicket(c *gin.Context) {
+	// API key clients can send their key in the WebSocket request headers.
+	value, exists := c.Get("claims")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, response.CodeUnauthorized)
+		return
 	}
 
-	if err := rows.Err(); err != nil {
-		log.Printf("Rows iteration error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error iterating rows"})
+	ticket, _, err := auth.IssueTicket(value.(*models.Claims))
+	if err != nil {
+		log.Printf("Error signing WebSocket ticket: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
 		return
 	}
-	cacheInstance.Set(cacheKey, schedules, 6*time.Hour)
+	response.Success(c, http.StatusOK, response.MsgTicketIssued, models.WebSocketTicket{
+		Ticket:    ticket,
+		ExpiresIn: int(auth.TicketTTL / time.Second),
+	})
+}
 
-	c.Header("X-Data-Source", "API")
-	c.JSON(http.StatusOK, schedules)
+func tokenPair(user models.User, refreshToken string) (models.TokenPair, error) {
+	accessToken, _, err := auth.Issue(user)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	return models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(auth.AccessTokenTTL / time.Second),
+	}, nil
 }
 
-func GetAllStasiun(c *gin.Context) {
+func CreateReview(c *gin.Context) {
 	db := database.GetDB()
-	if db == nil {
-		log.Println("Database connection is nil")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, response.CodeUnauthorized)
 		return
 	}
 
-	cacheKey := "all_stasiun"
-	cachedData, found := c.Get(cacheKey)
-	if found {
-		log.Println("fetching cached data")
-		c.Header("X-Data-Source", "Cache")
-		c.JSON(http.StatusOK, cachedData)
+	var review models.Review
+	if err := c.ShouldBindJSON(&review); err != nil {
+		response.BindError(c, err)
 		return
 	}
 
-	rows, err := db.Query("SELECT * FROM stations")
+	review.UserID = userID.(int)
+
+	var userExists bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)", review.UserID).Scan(&userExists)
 	if err != nil {
-		log.Printf("Error fetching stations: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching data"})
+		log.Printf("Error checking user existence: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	if !userExists {
+		response.Error(c, http.StatusNotFound, response.CodeUserNotFound)
 		return
 	}
-	defer rows.Close()
 
-	var stasiun []models.Stasiun
-	for rows.Next() {
-		var station models.Stasiun
-		if err := rows.Scan(&station.StasiunID, &station.StasiunName); err != nil {
-			log.Printf("Error scanning row: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error processing data"})
-			return
-		}
-		stasiun = append(stasiun, station)
+	query := `INSERT into reviews(user_id, rating, comment) VALUES ($1,$2,$3) RETURNING id, created_at`
+	err = db.QueryRow(query, review.UserID, review.Rating, review.Comment).Scan(&review.ID, &review.CreatedAt)
+	if err != nil {
+		log.Printf("Error inserting review: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
 	}
-	cacheInstance.Set(cacheKey, stasiun, 6*time.Hour)
-	c.Header("X-Data-Source", "API")
-	c.JSON(http.StatusOK, stasiun)
+	response.Success(c, http.StatusCreated, response.MsgReviewCreated, review)
 }
-func GetSchedulesByID(c *gin.Context) {
+
+var reviewSortColumns = map[string]string{
+	"id":         "id",
+	"rating":     "rating",
+	"created_at": "created_at",
+}
+
+func GetAllReviews(c *gin.Context) {
 	db := database.GetDB()
-	if db == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection is nil"})
-	}
-	stationIDStr := c.Param("id")
-	stationID, _ := strconv.Atoi(stationIDStr)
 
-	cacheKey := fmt.Sprintf("schedules_stations_%d", stationID)
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	orderBy, ok := parseSort(c, reviewSortColumns, "-created_at")
+	if !ok {
+		return
+	}
 
-	if cachedData, found := cacheInstance.Get(cacheKey); found {
-		c.Header("x-Data-Source", "cache")
-		c.JSON(http.StatusOK, cachedData)
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM reviews").Scan(&total); err != nil {
+		log.Printf("Error counting reviews: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
 		return
 	}
 
-	query := `
-	SELECT id, station_id, stasiun_name, arah, to_char(jadwal, 'HH24:MI') as jadwal 
-		FROM schedules WHERE station_id = $1
-		
-	`
-	rows, err := db.Query(query, stationID)
+	reviews := []models.Review{}
+
+	query := `SELECT id, rating, comment, created_at FROM reviews ORDER BY ` + orderBy + `, id DESC LIMIT $1 OFFSET $2`
+	rows, err := db.Query(query, page.Limit, page.Offset)
+
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		log.Printf("Error fetching reviews: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
 		return
 	}
 	defer rows.Close()
 
-	var schedules []models.Schedule
-
 	for rows.Next() {
-		var schedule models.Schedule
-		if err := rows.Scan(&schedule.ID, &schedule.StasiunID, &schedule.StasiunName, &schedule.Arah, &schedule.Jadwal); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		var review models.Review
+		if err := rows.Scan(&review.ID, &review.Rating, &review.Comment, &review.CreatedAt); err != nil {
+			log.Printf("Error scanning row: %v", err)
+			response.Error(c, http.StatusInternalServerError, response.CodeInternal)
 			return
 		}
-		schedules = append(schedules, schedule)
+		reviews = append(reviews, review)
 	}
-	cacheInstance.Set(cacheKey, schedules, 6*time.Hour)
+	response.SuccessWithMeta(c, http.StatusOK, response.MsgReviewsFetched, reviews, page.meta(total))
+}
 
-	c.Header("X-Data-Source", "API")
-	c.JSON(http.StatusOK, schedules)
+func GetAllSchedules(c *gin.Context) {
+	listSchedules(c)
 }
 
-func GetSchedulesByIDAndTrip(c *gin.Context) {
-	stationIDStr := c.Param("id")
-	arah := c.Param("arah")
-	cacheKey := fmt.Sprintf("%s_%s", stationIDStr, arah)
+func GetAllStasiun(c *gin.Context) {
+	c.Header("X-Data-Source", "Index")
+	response.Success(c, http.StatusOK, response.MsgStationsFetched, timetable.CurrentIndex().Stations())
+}
 
-	//load asia/jakarta
-	loc, err := time.LoadLocation("Asia/Jakarta")
-	if err != nil {
-		fmt.Println("Error loading location:", err)
+func GetSchedulesByID(c *gin.Context) {
+	stationIDStr := c.Param("id")
+	stationID, ok := parseStationID(c)
+	if !ok {
 		return
 	}
 
-	//get current time in the jakarta
-	now := time.Now().In(loc)
-
-	// Check if data is cached
-	if cachedData, found := cacheInstance.Get(cacheKey); found {
-		if schedules, ok := cachedData.([]models.Schedule); ok {
-			c.Header("X-Data-Source", "Cache")
-			c.JSON(http.StatusOK, schedules) // Return cached data
-			return
-		}
+	schedules := timetable.CurrentIndex().StationSchedules(stationID)
+	if len(schedules) == 0 {
+		response.Error(c, http.StatusNotFound, response.CodeStationNotFound, stationIDStr)
+		return
 	}
 
-	// Fetch schedules from database
-	db := database.GetDB()
-	if db == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection is nil"})
+	if renderSchedules(c, schedules, stationIDStr, "") {
 		return
 	}
-
-	var rows *sql.Rows
-	var queryErr error
-
-	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday {
-		rows, queryErr = db.Query(`
-		SELECT id, station_id, stasiun_name, arah, to_char(jadwal, 'HH24:MI') as jadwal 
-		FROM schedules 
-		WHERE  station_id = $1 AND arah = $2 AND stasiun_name = ''`, stationIDStr, arah)
-	} else {
-		rows, queryErr = db.Query(`
-		SELECT id, station_id, stasiun_name, arah, to_char(jadwal, 'HH24:MI') as jadwal 
-		FROM schedules 
-		WHERE  station_id = $1 AND arah = $2 AND stasiun_name <> ''
-	`, stationIDStr, arah)
+	c.Header("X-Data-Source", "Index")
+	data, ok := shapeSchedules(c, schedules)
+	if !ok {
+		return
 	}
+	response.Success(c, http.StatusOK, response.MsgStationSchedulesFetched, data, stationIDStr)
+}
 
-	if queryErr != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": queryErr.Error()})
+func GetSchedulesByIDAndTrip(c *gin.Context) {
+	stationIDStr := c.Param("id")
+	arah := c.Param("arah")
+	stationID, ok := parseStationID(c)
+	if !ok {
 		return
 	}
-	defer rows.Close()
-	// Process rows and filter out duplicates
-	seen := make(map[int]bool)
-	var uniqueSchedules []models.Schedule
 
-	for rows.Next() {
-		var s models.Schedule
-		var stasiunName sql.NullString
-		err := rows.Scan(&s.ID, &s.StasiunID, &stasiunName, &s.Arah, &s.Jadwal)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		// Check if the schedule with the same ID has been seen before
-		s.StasiunName = stasiunName.String
-		if !seen[s.ID] {
-			seen[s.ID] = true
-			uniqueSchedules = append(uniqueSchedules, s)
-		}
+	// Serve the weekday or weekend timetable depending on today in Jakarta
+	schedules := timetable.CurrentIndex().TripSchedules(stationID, arah, timetable.DayType(time.Now()))
+	if schedules == nil {
+		schedules = []models.Schedule{}
 	}
 
-	// Cache the fetched schedules
-	cacheInstance.Set(cacheKey, uniqueSchedules, 6*time.Hour)
-
-	// Set response header to indicate API source
-	c.Header("X-Data-Source", "API")
-	c.JSON(http.StatusOK, uniqueSchedules) // Return the fetched schedules
+	if renderSchedules(c, schedules, stationIDStr, arah) {
+		return
+	}
+	// Set response header to indicate the in-memory source
+	c.Header("X-Data-Source", "Index")
+	data, ok := shapeSchedules(c, schedules)
+	if !ok {
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgTripSchedulesFetched, data, stationIDStr, arah)
 }
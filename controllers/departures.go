@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"web-scrapper/models"
+	"web-scrapper/response"
+	"web-scrapper/timetable"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Number of departures returned per direction by GetNextDepartures and
+// StreamDepartures.
+const (
+	defaultDepartureLimit = 3
+	maxDepartureLimit     = 20
+)
+
+// streamKeepAlive is how often an idle departure stream sends a comment so
+// proxies keep the connection open.
+const streamKeepAlive = 30 * time.Second
+
+// departureQuery is the station, directions and per-direction limit asked for
+// by a departures request.
+type departureQuery struct {
+	StationID  int
+	Directions []string
+	Limit      int
+}
+
+// parseDepartureQuery reads :id, ?arah= and ?limit=, checking the station and
+// direction against the timetable index. It writes the error response itself
+// and reports false when that happened.
+func parseDepartureQuery(c *gin.Context) (departureQuery, bool) {
+	stationIDStr := c.Param("id")
+	stationID, ok := parseStationID(c)
+	if !ok {
+		return departureQuery{}, false
+	}
+
+	query := departureQuery{StationID: stationID, Limit: defaultDepartureLimit}
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "limit")
+			return query, false
+		}
+		query.Limit = min(parsed, maxDepartureLimit)
+	}
+
+	index := timetable.CurrentIndex()
+	if _, found := index.Station(stationID); !found {
+		response.Error(c, http.StatusNotFound, response.CodeStationNotFound, stationIDStr)
+		return query, false
+	}
+
+	query.Directions = index.Directions(stationID)
+	if arah := c.Query("arah"); arah != "" {
+		if len(index.Times(stationID, arah, models.DayWeekday)) == 0 && len(index.Times(stationID, arah, models.DayWeekend)) == 0 {
+			response.Error(c, http.StatusNotFound, response.CodeScheduleNotFound, stationIDStr, arah)
+			return query, false
+		}
+		query.Directions = []string{arah}
+	}
+	return query, true
+}
+
+// GetNextDepartures returns the next departures from a station in every
+// direction, or only in ?arah=, looked up in the timetable index.
+func GetNextDepartures(c *gin.Context) {
+	query, ok := parseDepartureQuery(c)
+	if !ok {
+		return
+	}
+
+	departures := nextDepartures(timetable.CurrentIndex(), query, time.Now())
+	c.Header("Cache-Control", "no-cache")
+	attachAlerts(c, query.StationID, c.Query("arah"))
+	response.Success(c, http.StatusOK, response.MsgDeparturesFetched, departures, c.Param("id"))
+}
+
+// departureUpdate is the payload of a "departures" event.
+type departureUpdate struct {
+	Reason     string             `json:"reason"`
+	Version    string             `json:"version"`
+	Departures []models.Departure `json:"departures"`
+}
+
+// Reasons a departure stream sends an update.
+const (
+	updateInitial          = "initial"
+	updateDeparted         = "departed"
+	updateTimetableUpdated = "timetable_updated"
+)
+
+// StreamDepartures pushes the next departures of a station as Server-Sent
+// Events. A "departures" event is sent on connect, whenever the earliest
+// departure has left and whenever a new timetable is swapped in.
+func StreamDepartures(c *gin.Context) {
+	query, ok := parseDepartureQuery(c)
+	if !ok {
+		return
+	}
+
+	updates, unsubscribe := timetable.Subscribe()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	c.Status(http.StatusOK)
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	reason := updateInitial
+	for {
+		index := timetable.CurrentIndex()
+		now := time.Now()
+		departures := nextDepartures(index, query, now)
+		c.SSEvent("departures", departureUpdate{Reason: reason, Version: index.Version().ETag, Departures: departures})
+		c.Writer.Flush()
+
+		reason, ok = waitForUpdate(c, updates, untilDeparted(departures, now), keepAlive)
+		if !ok {
+			return
+		}
+	}
+}
+
+// waitForUpdate blocks until the stream needs new departures and returns the
+// reason, sending keep-alive comments meanwhile. It reports false once the
+// client has disconnected.
+func waitForUpdate(c *gin.Context, updates <-chan timetable.Version, untilNext time.Duration, keepAlive *time.Ticker) (string, bool) {
+	departed := time.NewTimer(untilNext)
+	defer departed.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return "", false
+		case <-updates:
+			return updateTimetableUpdated, true
+		case <-departed.C:
+			return updateDeparted, true
+		case <-keepAlive.C:
+			c.Writer.WriteString(": keep-alive\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// untilDeparted returns how long until the earliest departure is no longer
+// the next one, which is once its minute has passed.
+func untilDeparted(departures []models.Departure, now time.Time) time.Duration {
+	if len(departures) == 0 {
+		return time.Hour
+	}
+	return departures[0].DepartsAt.Add(time.Minute).Sub(now)
+}
+
+// nextDepartures returns the next departures of the requested directions in
+// departure order.
+func nextDepartures(index *timetable.Index, query departureQuery, now time.Time) []models.Departure {
+	return index.UpcomingDepartures(query.StationID, query.Directions, now, query.Limit)
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"web-scrapper/export"
+	"web-scrapper/models"
+	"web-scrapper/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	shapeFlat    = "flat"
+	shapeGrouped = "grouped"
+)
+
+const (
+	formatJSON = "json"
+	formatICS  = "ics"
+	formatCSV  = "csv"
+)
+
+var filenameCleaner = regexp.MustCompile(`[^a-z0-9]+`)
+
+// negotiateFormat picks the representation requested through ?format= or,
+// when the query parameter is absent, through the Accept header. It reports
+// false when ?format= names an unsupported format.
+func negotiateFormat(c *gin.Context) (string, bool) {
+	if format := strings.ToLower(c.Query("format")); format != "" {
+		switch format {
+		case formatJSON, formatICS, formatCSV:
+			return format, true
+		}
+		return format, false
+	}
+
+	switch c.NegotiateFormat(gin.MIMEJSON, "text/calendar", "text/csv") {
+	case "text/calendar":
+		return formatICS, true
+	case "text/csv":
+		return formatCSV, true
+	}
+	return formatJSON, true
+}
+
+// renderSchedules writes schedules as iCalendar or CSV when the client asked
+// for one of them. It reports whether the response has been written, in which
+// case the caller must not render JSON.
+func renderSchedules(c *gin.Context, schedules []models.Schedule, stationID string, arah string) bool {
+	format, ok := negotiateFormat(c)
+	if !ok {
+		response.Error(c, http.StatusBadRequest, response.CodeUnsupportedFormat, format)
+		return true
+	}
+	if format == formatJSON {
+		return false
+	}
+
+	station := scheduleStationName(stationID, schedules)
+	name := strings.TrimSpace("MRT Jakarta " + station + " " + arah)
+
+	var buf bytes.Buffer
+	var contentType string
+	switch format {
+	case formatICS:
+		loc, err := time.LoadLocation("Asia/Jakarta")
+		if err != nil {
+			log.Printf("Error loading location: %v", err)
+			response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+			return true
+		}
+		err = export.WriteICS(&buf, schedules, export.ICSOptions{Name: name, Station: station, Now: time.Now(), Location: loc})
+		if err != nil {
+			log.Printf("Error rendering iCalendar: %v", err)
+			response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+			return true
+		}
+		contentType = "text/calendar; charset=utf-8"
+	case formatCSV:
+		if err := export.WriteCSV(&buf, schedules); err != nil {
+			log.Printf("Error rendering CSV: %v", err)
+			response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+			return true
+		}
+		contentType = "text/csv; charset=utf-8"
+	}
+
+	filename := strings.Trim(filenameCleaner.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+	return true
+}
+
+// scheduleStationName returns the station name carried by the weekday rows,
+// falling back to the station ID when only weekend rows are present.
+func scheduleStationName(stationID string, schedules []models.Schedule) string {
+	for _, schedule := range schedules {
+		if schedule.StasiunName != "" {
+			return schedule.StasiunName
+		}
+	}
+	return "Stasiun " + stationID
+}
+
+// shapeSchedules returns schedule data in the shape requested through
+// ?shape=: the flat list of rows by default, or times grouped by station,
+// direction and day type when shape=grouped. It writes the error response itself and
+// reports false when that happened.
+func shapeSchedules(c *gin.Context, data interface{}) (interface{}, bool) {
+	switch c.Query("shape") {
+	case "", shapeFlat:
+		return data, true
+	case shapeGrouped:
+		// Field selection applies to rows and has no meaning once grouped.
+		if c.Query(response.FieldsParam) != "" {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, response.FieldsParam)
+			return nil, false
+		}
+		if schedules, ok := data.([]models.Schedule); ok {
+			return groupSchedules(schedules), true
+		}
+		return data, true
+	}
+	response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "shape")
+	return nil, false
+}
+
+// groupSchedules groups departure times as station ID -> direction -> day
+// type -> sorted times, dropping the repeated station name and direction of
+// every row. Weekday and weekend times stay apart, since a time may only run
+// on one kind of day.
+func groupSchedules(schedules []models.Schedule) map[string]map[string]map[string][]string {
+	grouped := make(map[string]map[string]map[string][]string)
+	seen := make(map[string]bool)
+	for _, schedule := range schedules {
+		station := strconv.Itoa(schedule.StasiunID)
+		day := schedule.DayType()
+		key := station + "|" + schedule.Arah + "|" + day + "|" + schedule.Jadwal
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if grouped[station] == nil {
+			grouped[station] = make(map[string]map[string][]string)
+		}
+		if grouped[station][schedule.Arah] == nil {
+			grouped[station][schedule.Arah] = make(map[string][]string)
+		}
+		grouped[station][schedule.Arah][day] = append(grouped[station][schedule.Arah][day], schedule.Jadwal)
+	}
+	for _, directions := range grouped {
+		for _, days := range directions {
+			for _, times := range days {
+				sort.Strings(times)
+			}
+		}
+	}
+	return grouped
+}
@@ -0,0 +1,17 @@
+package controllers
+
+import (
+	"net/http"
+
+	"web-scrapper/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS publishes the public keys that verify tokens signed with RS256 or
+// EdDSA. It answers with a bare JSON Web Key Set rather than the response
+// envelope, as verifiers expect.
+func GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, auth.PublicKeys())
+}
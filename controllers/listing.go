@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"web-scrapper/models"
+	"web-scrapper/response"
+	"web-scrapper/timetable"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Page size limits enforced on every list endpoint.
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 500
+)
+
+// pageParams is the offset-based window requested through ?limit= and ?offset=.
+type pageParams struct {
+	Limit  int
+	Offset int
+}
+
+// meta builds the pagination metadata returned alongside a page of results.
+func (p pageParams) meta(total int) response.Page {
+	page := response.Page{Limit: p.Limit, Offset: p.Offset, Total: total}
+	if next := p.Offset + p.Limit; next < total {
+		page.NextOffset = &next
+	}
+	return page
+}
+
+// parsePage reads ?limit= and ?offset=, capping the limit at maxPageLimit.
+// It writes the error response itself and reports false when that happened.
+func parsePage(c *gin.Context) (pageParams, bool) {
+	page := pageParams{Limit: defaultPageLimit}
+
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "limit")
+			return page, false
+		}
+		page.Limit = min(value, maxPageLimit)
+	}
+	if offset := c.Query("offset"); offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "offset")
+			return page, false
+		}
+		page.Offset = value
+	}
+	return page, true
+}
+
+// sortField is one field of ?sort=, descending when prefixed with "-".
+type sortField struct {
+	Name string
+	Desc bool
+}
+
+// parseSortFields reads ?sort=, a comma separated list of the fields in
+// allowed. It writes the error response itself and reports false when that
+// happened.
+func parseSortFields(c *gin.Context, allowed map[string]string, fallback string) ([]sortField, bool) {
+	value := c.Query("sort")
+	if value == "" {
+		value = fallback
+	}
+
+	var fields []sortField
+	for _, name := range strings.Split(value, ",") {
+		field := sortField{Name: name}
+		if strings.HasPrefix(name, "-") {
+			field = sortField{Name: name[1:], Desc: true}
+		}
+		if _, ok := allowed[field.Name]; !ok {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "sort")
+			return nil, false
+		}
+		fields = append(fields, field)
+	}
+	return fields, true
+}
+
+// parseSort maps ?sort= to an ORDER BY clause; allowed maps the public field
+// names to their columns.
+func parseSort(c *gin.Context, allowed map[string]string, fallback string) (string, bool) {
+	fields, ok := parseSortFields(c, allowed, fallback)
+	if !ok {
+		return "", false
+	}
+
+	clauses := make([]string, len(fields))
+	for i, field := range fields {
+		direction := "ASC"
+		if field.Desc {
+			direction = "DESC"
+		}
+		clauses[i] = allowed[field.Name] + " " + direction
+	}
+	return strings.Join(clauses, ", "), true
+}
+
+// scheduleFilter narrows the schedule list by station, direction, day type
+// and departure time window.
+type scheduleFilter struct {
+	StationID int
+	Arah      string
+	Day       string
+	FromTime  string
+	ToTime    string
+}
+
+var scheduleSortColumns = map[string]string{
+	"id":         "id",
+	"station_id": "station_id",
+	"arah":       "arah",
+	"jadwal":     "jadwal",
+}
+
+// parseScheduleFilter reads the schedule filters from the query string. It
+// writes the error response itself and reports false when that happened.
+func parseScheduleFilter(c *gin.Context) (scheduleFilter, bool) {
+	var filter scheduleFilter
+
+	if stationID := c.Query("station_id"); stationID != "" {
+		value, err := strconv.Atoi(stationID)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "station_id")
+			return filter, false
+		}
+		filter.StationID = value
+	}
+
+	filter.Arah = c.Query("arah")
+
+	filter.Day = strings.ToLower(c.Query("day"))
+	if filter.Day != "" && filter.Day != models.DayWeekday && filter.Day != models.DayWeekend {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "day")
+		return filter, false
+	}
+
+	for _, param := range []struct {
+		name  string
+		value *string
+	}{{"from_time", &filter.FromTime}, {"to_time", &filter.ToTime}} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse("15:04", value)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, param.name)
+			return filter, false
+		}
+		*param.value = parsed.Format("15:04")
+	}
+	return filter, true
+}
+
+// match reports whether a schedule passes the filter.
+func (f scheduleFilter) match(schedule models.Schedule) bool {
+	switch {
+	case f.StationID != 0 && schedule.StasiunID != f.StationID,
+		f.Arah != "" && schedule.Arah != f.Arah,
+		f.Day != "" && schedule.DayType() != f.Day,
+		f.FromTime != "" && schedule.Jadwal < f.FromTime,
+		f.ToTime != "" && schedule.Jadwal > f.ToTime:
+		return false
+	}
+	return true
+}
+
+// compareSchedules orders two schedules by one sort field.
+func compareSchedules(a, b models.Schedule, field string) int {
+	switch field {
+	case "station_id":
+		return a.StasiunID - b.StasiunID
+	case "arah":
+		return strings.Compare(a.Arah, b.Arah)
+	case "jadwal":
+		return strings.Compare(a.Jadwal, b.Jadwal)
+	}
+	return a.ID - b.ID
+}
+
+// selectSchedules returns one page of the filtered and sorted schedules and
+// the total number of schedules matching the filter. Rows are tie-broken by
+// id so pages stay stable.
+func selectSchedules(all []models.Schedule, filter scheduleFilter, order []sortField, page pageParams) ([]models.Schedule, int) {
+	matched := make([]models.Schedule, 0, len(all))
+	for _, schedule := range all {
+		if filter.match(schedule) {
+			matched = append(matched, schedule)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		for _, field := range order {
+			if cmp := compareSchedules(matched[i], matched[j], field.Name); cmp != 0 {
+				return (cmp < 0) != field.Desc
+			}
+		}
+		return matched[i].ID < matched[j].ID
+	})
+
+	total := len(matched)
+	start := min(page.Offset, total)
+	end := min(start+page.Limit, total)
+	return matched[start:end], total
+}
+
+// parseStationID reads the :id path parameter. It writes the error response
+// itself and reports false when that happened.
+func parseStationID(c *gin.Context) (int, bool) {
+	stationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidStationID, c.Param("id"))
+		return 0, false
+	}
+	return stationID, true
+}
+
+// listSchedules answers a schedule list request from the timetable index.
+func listSchedules(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	filter, ok := parseScheduleFilter(c)
+	if !ok {
+		return
+	}
+	order, ok := parseSortFields(c, scheduleSortColumns, "id")
+	if !ok {
+		return
+	}
+
+	schedules, total := selectSchedules(timetable.CurrentIndex().Schedules(), filter, order, page)
+	c.Header("X-Data-Source", "Index")
+	data, ok := shapeSchedules(c, schedules)
+	if !ok {
+		return
+	}
+	response.SuccessWithMeta(c, http.StatusOK, response.MsgSchedulesFetched, data, page.meta(total))
+}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"web-scrapper/auth"
+	"web-scrapper/database"
+	"web-scrapper/i18n"
+	"web-scrapper/mail"
+	"web-scrapper/models"
+	"web-scrapper/response"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ChangePassword sets a new password for the current user after checking the
+// current one. Every other session ends, so the response holds new tokens
+// for this one.
+func ChangePassword(c *gin.Context) {
+	// API keys have no password.
+	value, exists := c.Get("claims")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, response.CodeUnauthorized)
+		return
+	}
+	claims := value.(*models.Claims)
+
+	var input models.PasswordChangeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	db := database.GetDB()
+	var current string
+	if err := db.QueryRow("SELECT password FROM users WHERE id = $1", claims.UserID).Scan(&current); err != nil {
+		userError(c, err, "Error fetching user")
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(current), []byte(input.CurrentPassword)); err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeIncorrectPassword)
+		return
+	}
+
+	hashedPassword, err := hashPassword(input.NewPassword)
+	if err != nil {
+		log.Printf("Error hashing password: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	generation, err := auth.SetPassword(db, claims.UserID, hashedPassword)
+	if err != nil {
+		userError(c, err, "Error changing password")
+		return
+	}
+	if err := auth.RevokeAccessToken(db, claims); err != nil {
+		log.Println(err)
+	}
+
+	refreshToken, err := auth.IssueRefreshToken(db, claims.UserID)
+	if err != nil {
+		log.Printf("Error issuing refresh token: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	user := models.User{ID: claims.UserID, Username: claims.Username, Role: c.GetString("role"), TokenGeneration: generation}
+	pair, err := tokenPair(user, refreshToken)
+	if err != nil {
+		log.Printf("Error signing token: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgPasswordChanged, pair)
+}
+
+// Reset requests are throttled per email, so a mailbox cannot be flooded,
+// and per client IP, so emails cannot be probed in bulk.
+var (
+	resetEmailThrottle = auth.NewThrottle(3, time.Hour)
+	resetIPThrottle    = auth.NewThrottle(10, time.Hour)
+)
+
+// ForgotPassword mails a reset token to the user with the given email. The
+// answer is the same whether or not such a user exists and is sent before
+// the user is looked up, so neither its content nor its timing tells who has
+// an account.
+func ForgotPassword(c *gin.Context) {
+	var input models.PasswordForgotInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	for _, limit := range []struct {
+		throttle *auth.Throttle
+		key      string
+	}{
+		{resetIPThrottle, c.ClientIP()},
+		{resetEmailThrottle, strings.ToLower(input.Email)},
+	} {
+		if allowed, retryIn := limit.throttle.Allow(limit.key); !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(retryIn.Seconds())+1))
+			response.Error(c, http.StatusTooManyRequests, response.CodeTooManyRequests)
+			return
+		}
+	}
+
+	go sendPasswordReset(database.GetDB(), input.Email, response.Lang(c))
+	response.Success(c, http.StatusOK, response.MsgPasswordResetRequested, nil)
+}
+
+// sendPasswordReset mails a reset token in lang to the active user with the
+// given email, if there is one.
+func sendPasswordReset(db *sql.DB, email string, lang string) {
+	var user models.User
+	err := db.QueryRow("SELECT id, username, email FROM users WHERE LOWER(email) = LOWER($1) AND disabled_at IS NULL", email).
+		Scan(&user.ID, &user.Username, &user.Email)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching user: %v", err)
+		return
+	}
+
+	token, err := auth.CreatePasswordReset(db, user.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	err = mail.Send(mail.Message{
+		To:      user.Email,
+		Subject: i18n.T(lang, "PASSWORD_RESET_MAIL_SUBJECT"),
+		Body:    i18n.T(lang, "PASSWORD_RESET_MAIL_BODY", user.Username, int(auth.PasswordResetTTL.Minutes()), resetLink(token)),
+	})
+	if err != nil {
+		log.Printf("Error sending password reset mail: %v", err)
+	}
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword. The
+// token cannot be used again and every session of the user ends.
+func ResetPassword(c *gin.Context) {
+	var input models.PasswordResetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	hashedPassword, err := hashPassword(input.NewPassword)
+	if err != nil {
+		log.Printf("Error hashing password: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	_, err = auth.ResetPassword(database.GetDB(), input.Token, hashedPassword)
+	if err == auth.ErrInvalidResetToken {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidResetToken)
+		return
+	}
+	if err != nil {
+		log.Printf("Error resetting password: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgPasswordReset, nil)
+}
+
+// resetLink puts a reset token in the page configured by
+// PASSWORD_RESET_URL, or returns the bare token when there is none.
+func resetLink(token string) string {
+	page := os.Getenv("PASSWORD_RESET_URL")
+	if page == "" {
+		return token
+	}
+	separator := "?"
+	if strings.Contains(page, "?") {
+		separator = "&"
+	}
+	return page + separator + "token=" + token
+}
+
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hashed), err
+}
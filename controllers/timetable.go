@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"web-scrapper/export"
+	"web-scrapper/response"
+	"web-scrapper/timetable"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStationTimetableHTML renders a station's departure board as a printable HTML poster.
+func GetStationTimetableHTML(c *gin.Context) {
+	board, ok := loadStationTimetable(c)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := export.WriteHTML(&buf, board); err != nil {
+		log.Printf("Error rendering timetable HTML: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// GetStationTimetablePDF renders a station's departure board as a printable PDF.
+func GetStationTimetablePDF(c *gin.Context) {
+	board, ok := loadStationTimetable(c)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := export.WritePDF(&buf, board); err != nil {
+		log.Printf("Error rendering timetable PDF: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	c.Header("Content-Disposition", `inline; filename="timetable-`+strconv.Itoa(board.StationID)+`.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// loadStationTimetable looks the station and its schedules up in the
+// timetable index. It writes the error response itself and reports false when
+// that happened.
+func loadStationTimetable(c *gin.Context) (export.Timetable, bool) {
+	stationID, ok := parseStationID(c)
+	if !ok {
+		return export.Timetable{}, false
+	}
+
+	index := timetable.CurrentIndex()
+	station, found := index.Station(stationID)
+	if !found {
+		response.Error(c, http.StatusNotFound, response.CodeStationNotFound, c.Param("id"))
+		return export.Timetable{}, false
+	}
+
+	return export.BuildTimetable(station.StasiunID, station.StasiunName, index.StationSchedules(stationID), time.Now().In(timetable.Location())), true
+}
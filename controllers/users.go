@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"web-scrapper/auth"
+	"web-scrapper/database"
+	"web-scrapper/models"
+	"web-scrapper/response"
+	"web-scrapper/users"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListUsers lists the users, optionally searched by ?q= (part of the
+// username or email) and filtered by ?role= and ?disabled=.
+func ListUsers(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	filter := users.Filter{Query: c.Query("q"), Role: c.Query("role")}
+	if value := c.Query("disabled"); value != "" {
+		disabled, err := strconv.ParseBool(value)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "disabled")
+			return
+		}
+		filter.Disabled = &disabled
+	}
+
+	list, total, err := users.List(database.GetDB(), filter, page.Limit, page.Offset)
+	if err != nil {
+		log.Printf("Error fetching users: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	response.SuccessWithMeta(c, http.StatusOK, response.MsgUsersFetched, list, page.meta(total))
+}
+
+func GetUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := users.Get(database.GetDB(), id)
+	if err != nil {
+		userError(c, err, "Error fetching user")
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgUserFetched, user)
+}
+
+// UpdateUserRole promotes or demotes a user. Admins cannot change their own
+// role, so there is always an admin left.
+func UpdateUserRole(c *gin.Context) {
+	id, ok := parseOtherUserID(c)
+	if !ok {
+		return
+	}
+	var input models.RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BindError(c, err)
+		return
+	}
+	if !auth.IsRole(input.Role) {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "role")
+		return
+	}
+
+	user, err := users.SetRole(database.GetDB(), id, input.Role)
+	if err != nil {
+		userError(c, err, "Error updating user role")
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgUserRoleUpdated, user)
+}
+
+// DisableUser locks an account out and ends its sessions.
+func DisableUser(c *gin.Context) {
+	id, ok := parseOtherUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := users.SetDisabled(database.GetDB(), id, true)
+	if err != nil {
+		userError(c, err, "Error disabling user")
+		return
+	}
+	if err := auth.RevokeUserSessions(database.GetDB(), id); err != nil {
+		log.Println(err)
+	}
+	response.Success(c, http.StatusOK, response.MsgUserDisabled, user, strconv.Itoa(id))
+}
+
+func EnableUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := users.SetDisabled(database.GetDB(), id, false)
+	if err != nil {
+		userError(c, err, "Error enabling user")
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgUserEnabled, user, strconv.Itoa(id))
+}
+
+// DeleteUser deletes an account. ?reviews=anonymize (the default) keeps the
+// user's reviews without an author and ?reviews=delete removes them.
+func DeleteUser(c *gin.Context) {
+	id, ok := parseOtherUserID(c)
+	if !ok {
+		return
+	}
+	policy := c.DefaultQuery("reviews", users.ReviewsAnonymize)
+	if policy != users.ReviewsAnonymize && policy != users.ReviewsDelete {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "reviews")
+		return
+	}
+
+	if err := users.Delete(database.GetDB(), id, policy); err != nil {
+		userError(c, err, "Error deleting user")
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgUserDeleted, nil, strconv.Itoa(id))
+}
+
+// parseUserID reads the :id path parameter. It writes the error response
+// itself and reports false when that happened.
+func parseUserID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "id")
+		return 0, false
+	}
+	return id, true
+}
+
+// parseOtherUserID is parseUserID for operations admins may not apply to
+// their own account.
+func parseOtherUserID(c *gin.Context) (int, bool) {
+	id, ok := parseUserID(c)
+	if ok && id == c.GetInt("user_id") {
+		response.Error(c, http.StatusConflict, response.CodeCannotModifySelf)
+		return 0, false
+	}
+	return id, ok
+}
+
+// userError answers 404 for a missing user and 500 for anything else.
+func userError(c *gin.Context, err error, context string) {
+	if err == sql.ErrNoRows {
+		response.Error(c, http.StatusNotFound, response.CodeUserNotFound)
+		return
+	}
+	log.Printf("%s: %v", context, err)
+	response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+}
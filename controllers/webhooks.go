@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"web-scrapper/database"
+	"web-scrapper/models"
+	"web-scrapper/response"
+	"web-scrapper/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ListWebhooks(c *gin.Context) {
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	list, total, err := webhooks.List(database.GetDB(), page.Limit, page.Offset)
+	if err != nil {
+		log.Printf("Error fetching webhooks: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	response.SuccessWithMeta(c, http.StatusOK, response.MsgWebhooksFetched, list, page.meta(total))
+}
+
+func GetWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := webhooks.Get(database.GetDB(), id)
+	if err != nil {
+		webhookError(c, err, "Error fetching webhook")
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgWebhookFetched, webhook)
+}
+
+// CreateWebhook registers a webhook. The response is the only place its
+// signing secret is shown.
+func CreateWebhook(c *gin.Context) {
+	var input models.WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	webhook, err := webhooks.Create(database.GetDB(), input, c.GetInt("user_id"))
+	if err != nil {
+		log.Printf("Error creating webhook: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	response.Success(c, http.StatusCreated, response.MsgWebhookCreated, webhook)
+}
+
+func UpdateWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+	var input models.WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	webhook, err := webhooks.Update(database.GetDB(), id, input)
+	if err != nil {
+		webhookError(c, err, "Error updating webhook")
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgWebhookUpdated, webhook)
+}
+
+func DeleteWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := webhooks.Delete(database.GetDB(), id); err != nil {
+		webhookError(c, err, "Error deleting webhook")
+		return
+	}
+	response.Success(c, http.StatusOK, response.MsgWebhookDeleted, nil, strconv.Itoa(id))
+}
+
+// ListWebhookDeliveries is the delivery log of a webhook, newest first,
+// optionally filtered by ?status=.
+func ListWebhookDeliveries(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+	page, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryFailed:
+	default:
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "status")
+		return
+	}
+
+	if _, err := webhooks.Get(database.GetDB(), id); err != nil {
+		webhookError(c, err, "Error fetching webhook")
+		return
+	}
+	deliveries, total, err := webhooks.Deliveries(database.GetDB(), id, status, page.Limit, page.Offset)
+	if err != nil {
+		log.Printf("Error fetching webhook deliveries: %v", err)
+		response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+		return
+	}
+	response.SuccessWithMeta(c, http.StatusOK, response.MsgWebhookDeliveriesFetched, deliveries, page.meta(total))
+}
+
+// parseWebhookID reads the :id path parameter. It writes the error response
+// itself and reports false when that happened.
+func parseWebhookID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, response.CodeInvalidParameter, "id")
+		return 0, false
+	}
+	return id, true
+}
+
+// webhookError answers 404 for a missing webhook and 500 for anything else.
+func webhookError(c *gin.Context, err error, context string) {
+	if err == sql.ErrNoRows {
+		response.Error(c, http.StatusNotFound, response.CodeWebhookNotFound, c.Param("id"))
+		return
+	}
+	log.Printf("%s: %v", context, err)
+	response.Error(c, http.StatusInternalServerError, response.CodeInternal)
+}
@@ -0,0 +1,30 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"web-scrapper/models"
+)
+
+// CSVHeader matches the header written by the scraper to data/stasiunSchedules.csv.
+var CSVHeader = []string{"StasiunID", "StasiunName", "Arah", "Schedule"}
+
+// WriteCSV writes schedules using the same layout as data/stasiunSchedules.csv.
+func WriteCSV(w io.Writer, schedules []models.Schedule) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, schedule := range schedules {
+		record := []string{strconv.Itoa(schedule.StasiunID), schedule.StasiunName, schedule.Arah, schedule.Jadwal}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
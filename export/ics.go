@@ -0,0 +1,155 @@
+package export
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"web-scrapper/models"
+)
+
+const (
+	icsTimezone = "Asia/Jakarta"
+	icsLineMax  = 75
+)
+
+// ICSOptions controls how a timetable is rendered as an iCalendar feed.
+type ICSOptions struct {
+	// Name is used as the calendar name.
+	Name string
+	// Station is used as the event location for rows that carry no station name.
+	Station string
+	// Now anchors the first occurrence of each recurring event.
+	Now time.Time
+	// Location is the timezone the departure times are expressed in.
+	Location *time.Location
+}
+
+// WriteICS renders schedules as RFC 5545 recurring events. Weekday departures
+// repeat Monday to Friday and weekend departures on Saturday and Sunday.
+func WriteICS(w io.Writer, schedules []models.Schedule, opts ICSOptions) error {
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	now := opts.Now.In(loc)
+	stamp := opts.Now.UTC().Format("20060102T150405Z")
+
+	bw := bufio.NewWriter(w)
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:-//mrt-api//Jakarta MRT Timetable//ID")
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	writeLine(bw, "METHOD:PUBLISH")
+	writeLine(bw, "X-WR-CALNAME:"+escapeText(opts.Name))
+	writeLine(bw, "X-WR-TIMEZONE:"+icsTimezone)
+
+	// Jakarta has no daylight saving time, so a single STANDARD component is enough.
+	writeLine(bw, "BEGIN:VTIMEZONE")
+	writeLine(bw, "TZID:"+icsTimezone)
+	writeLine(bw, "BEGIN:STANDARD")
+	writeLine(bw, "DTSTART:19700101T000000")
+	writeLine(bw, "TZOFFSETFROM:+0700")
+	writeLine(bw, "TZOFFSETTO:+0700")
+	writeLine(bw, "TZNAME:WIB")
+	writeLine(bw, "END:STANDARD")
+	writeLine(bw, "END:VTIMEZONE")
+
+	seen := make(map[string]bool)
+	for _, schedule := range schedules {
+		uid := eventUID(schedule)
+		if seen[uid] {
+			continue
+		}
+		seen[uid] = true
+
+		departure, err := time.Parse("15:04", schedule.Jadwal)
+		if err != nil {
+			return fmt.Errorf("error parsing departure time %q: %v", schedule.Jadwal, err)
+		}
+
+		day, byDay := firstDay(now, schedule.DayType())
+		start := time.Date(day.Year(), day.Month(), day.Day(), departure.Hour(), departure.Minute(), 0, 0, loc)
+
+		summary := fmt.Sprintf("MRT %s %s", schedule.Arah, schedule.Jadwal)
+		location := schedule.StasiunName
+		if location == "" {
+			location = opts.Station
+		}
+
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, "UID:"+uid)
+		writeLine(bw, "DTSTAMP:"+stamp)
+		writeLine(bw, fmt.Sprintf("DTSTART;TZID=%s:%s", icsTimezone, start.Format("20060102T150405")))
+		writeLine(bw, "DURATION:PT1M")
+		writeLine(bw, "RRULE:FREQ=WEEKLY;BYDAY="+byDay)
+		writeLine(bw, "SUMMARY:"+escapeText(summary))
+		if location != "" {
+			writeLine(bw, "LOCATION:"+escapeText(location))
+		}
+		writeLine(bw, "CATEGORIES:"+strings.ToUpper(schedule.DayType()))
+		writeLine(bw, "TRANSP:TRANSPARENT")
+		writeLine(bw, "END:VEVENT")
+	}
+
+	writeLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+// eventUID identifies the event of a departure by its station, direction,
+// day type and time. Schedule IDs change with every scrape, so calendars
+// that already hold the event update it instead of adding a copy.
+func eventUID(schedule models.Schedule) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%d\n%s\n%s\n%s", schedule.StasiunID, schedule.Arah, schedule.DayType(), schedule.Jadwal)))
+	return fmt.Sprintf("departure-%d-%s@mrt-api", schedule.StasiunID, hex.EncodeToString(hash[:8]))
+}
+
+// firstDay returns the first date on or after now that belongs to the given
+// day type, along with the BYDAY list of the recurrence.
+func firstDay(now time.Time, dayType string) (time.Time, string) {
+	weekend := dayType == models.DayWeekend
+	day := now
+	for {
+		isWeekend := day.Weekday() == time.Saturday || day.Weekday() == time.Sunday
+		if isWeekend == weekend {
+			break
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	if weekend {
+		return day, "SA,SU"
+	}
+	return day, "MO,TU,WE,TH,FR"
+}
+
+// writeLine writes a content line terminated by CRLF, folding it at 75 octets
+// without splitting multi-byte characters.
+func writeLine(w *bufio.Writer, line string) {
+	limit := icsLineMax
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards the limit.
+		limit = icsLineMax - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+func escapeText(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return replacer.Replace(s)
+}
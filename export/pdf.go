@@ -0,0 +1,166 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 portrait in PDF points.
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 40.0
+)
+
+// pdfDocument is a minimal PDF 1.4 writer supporting text in the standard
+// Helvetica fonts, lines and filled rectangles. It is enough to print a
+// timetable without pulling in a PDF library.
+type pdfDocument struct {
+	pages []*bytes.Buffer
+}
+
+func (d *pdfDocument) addPage() *bytes.Buffer {
+	page := &bytes.Buffer{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// pdfText draws s with its baseline at (x, y). Bold selects Helvetica-Bold.
+func pdfText(page *bytes.Buffer, x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(s))
+}
+
+func pdfLine(page *bytes.Buffer, x1, y1, x2, y2 float64) {
+	fmt.Fprintf(page, "%.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+func pdfFillRect(page *bytes.Buffer, x, y, w, h float64, r, g, b float64) {
+	fmt.Fprintf(page, "q %.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f Q\n", r, g, b, x, y, w, h)
+}
+
+// pdfEscape escapes string delimiters and replaces characters outside of
+// Latin-1, which the standard fonts cannot show.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r > 255:
+			b.WriteByte('?')
+		case r > 126:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (d *pdfDocument) writeTo(w io.Writer) error {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-4 are the catalog, the page tree and the two fonts; every
+	// page then takes two objects, the page itself and its content stream.
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// WritePDF renders the timetable as a printable PDF with one A4 page per
+// direction and day type.
+func WritePDF(w io.Writer, timetable Timetable) error {
+	const (
+		hourWidth    = 44.0
+		minuteWidth  = 33.0
+		rowHeight    = 28.0
+		minutesInRow = 14
+	)
+
+	doc := &pdfDocument{}
+	sections := timetable.Sections
+	if len(sections) == 0 {
+		page := doc.addPage()
+		pdfText(page, pdfMargin, pdfPageHeight-pdfMargin-24, 24, true, timetable.StationName)
+		pdfText(page, pdfMargin, pdfPageHeight-pdfMargin-56, 12, false, "Belum ada jadwal untuk stasiun ini.")
+		return doc.writeTo(w)
+	}
+
+	for _, section := range sections {
+		page := doc.addPage()
+		y := pdfPageHeight - pdfMargin - 24
+		pdfText(page, pdfMargin, y, 24, true, timetable.StationName)
+		y -= 26
+		pdfText(page, pdfMargin, y, 14, false, section.Arah+"  -  "+section.Label())
+		y -= 20
+
+		for _, hour := range section.Hours {
+			// Hours with more departures than fit in a row wrap onto extra lines.
+			lines := (len(hour.Minutes) + minutesInRow - 1) / minutesInRow
+			if lines == 0 {
+				lines = 1
+			}
+			height := rowHeight * float64(lines)
+			if y-height < pdfMargin+20 {
+				page = doc.addPage()
+				y = pdfPageHeight - pdfMargin
+			}
+			top := y
+			y -= height
+
+			pdfFillRect(page, pdfMargin, y, hourWidth, height, 0, 0.318, 0.620)
+			page.WriteString("1 g\n")
+			pdfText(page, pdfMargin+12, top-rowHeight/2-5, 14, true, hour.Hour)
+			page.WriteString("0 g\n")
+
+			for i, minute := range hour.Minutes {
+				line := i / minutesInRow
+				col := i % minutesInRow
+				x := pdfMargin + hourWidth + float64(col)*minuteWidth + 9
+				pdfText(page, x, top-float64(line)*rowHeight-rowHeight/2-4, 12, false, minute)
+			}
+
+			pdfLine(page, pdfMargin, y, pdfPageWidth-pdfMargin, y)
+		}
+		pdfText(page, pdfMargin, pdfMargin, 8, false,
+			"Sumber: jakartamrt.co.id - Dibuat "+timetable.GeneratedAt.Format("02-01-2006 15:04")+" WIB")
+	}
+	return doc.writeTo(w)
+}
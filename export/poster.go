@@ -0,0 +1,49 @@
+package export
+
+import (
+	"html/template"
+	"io"
+)
+
+var posterTemplate = template.Must(template.New("poster").Parse(`<!DOCTYPE html>
+<html lang="id">
+<head>
+<meta charset="utf-8">
+<title>Jadwal Keberangkatan {{.StationName}}</title>
+<style>
+@page { size: A4 portrait; margin: 12mm; }
+body { font-family: Helvetica, Arial, sans-serif; color: #1a1a1a; margin: 0; }
+.section { page-break-after: always; padding: 8mm 0; }
+.section:last-child { page-break-after: auto; }
+h1 { font-size: 26pt; margin: 0; }
+h2 { font-size: 16pt; margin: 4pt 0 12pt; font-weight: normal; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #999; padding: 4pt 6pt; font-size: 12pt; }
+th.hour { width: 48pt; background: #00519e; color: #fff; font-size: 14pt; text-align: center; }
+td.minute { text-align: center; width: 28pt; }
+.footer { margin-top: 10pt; font-size: 8pt; color: #666; }
+</style>
+</head>
+<body>
+{{- range .Sections}}
+<div class="section">
+<h1>{{$.StationName}}</h1>
+<h2>{{.Arah}} &middot; {{.Label}}</h2>
+<table>
+{{- range .Hours}}
+<tr><th class="hour">{{.Hour}}</th>{{range .Minutes}}<td class="minute">{{.}}</td>{{end}}</tr>
+{{- end}}
+</table>
+<p class="footer">Sumber: jakartamrt.co.id &middot; Dibuat {{$.GeneratedAt.Format "02-01-2006 15:04"}} WIB</p>
+</div>
+{{- else}}
+<p>Belum ada jadwal untuk {{.StationName}}.</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML renders the timetable as a printable HTML poster.
+func WriteHTML(w io.Writer, timetable Timetable) error {
+	return posterTemplate.Execute(w, timetable)
+}
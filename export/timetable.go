@@ -0,0 +1,92 @@
+package export
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"web-scrapper/models"
+)
+
+// Timetable is a station's departure board laid out by hour, one section per
+// direction and day type.
+type Timetable struct {
+	StationID   int
+	StationName string
+	GeneratedAt time.Time
+	Sections    []TimetableSection
+}
+
+// TimetableSection lists the departures of one direction for one day type.
+type TimetableSection struct {
+	Arah    string
+	DayType string
+	Hours   []TimetableHour
+}
+
+// TimetableHour holds the departure minutes within a single hour.
+type TimetableHour struct {
+	Hour    string
+	Minutes []string
+}
+
+// Label returns the human readable day type of the section.
+func (s TimetableSection) Label() string {
+	if s.DayType == models.DayWeekend {
+		return "Sabtu, Minggu & Libur / Weekend & Holiday"
+	}
+	return "Senin - Jumat / Weekday"
+}
+
+// BuildTimetable groups a station's schedules into hour rows per direction
+// and day type. Sections are ordered by direction with weekdays first.
+func BuildTimetable(stationID int, stationName string, schedules []models.Schedule, generatedAt time.Time) Timetable {
+	type sectionKey struct {
+		arah    string
+		dayType string
+	}
+	grouped := make(map[sectionKey]map[string]map[string]bool)
+	for _, schedule := range schedules {
+		hour, minute, ok := strings.Cut(schedule.Jadwal, ":")
+		if !ok {
+			continue
+		}
+		key := sectionKey{arah: schedule.Arah, dayType: schedule.DayType()}
+		if grouped[key] == nil {
+			grouped[key] = make(map[string]map[string]bool)
+		}
+		if grouped[key][hour] == nil {
+			grouped[key][hour] = make(map[string]bool)
+		}
+		grouped[key][hour][minute] = true
+	}
+
+	keys := make([]sectionKey, 0, len(grouped))
+	for key := range grouped {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].arah != keys[j].arah {
+			return keys[i].arah < keys[j].arah
+		}
+		return keys[i].dayType == models.DayWeekday && keys[j].dayType != models.DayWeekday
+	})
+
+	timetable := Timetable{StationID: stationID, StationName: stationName, GeneratedAt: generatedAt}
+	for _, key := range keys {
+		section := TimetableSection{Arah: key.arah, DayType: key.dayType}
+		for hour, minutes := range grouped[key] {
+			row := TimetableHour{Hour: hour}
+			for minute := range minutes {
+				row.Minutes = append(row.Minutes, minute)
+			}
+			sort.Strings(row.Minutes)
+			section.Hours = append(section.Hours, row)
+		}
+		sort.Slice(section.Hours, func(i, j int) bool {
+			return section.Hours[i].Hour < section.Hours[j].Hour
+		})
+		timetable.Sections = append(timetable.Sections, section)
+	}
+	return timetable
+}
@@ -0,0 +1,90 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported languages. Indonesian stays the default so existing clients keep
+// receiving the messages they were built against.
+const (
+	ID      = "id"
+	EN      = "en"
+	Default = ID
+)
+
+// ContextKey is the gin context key holding the language of the current request.
+const ContextKey = "lang"
+
+// Message holds the translations of a single catalog entry.
+type Message struct {
+	ID string
+	EN string
+}
+
+// T returns the message registered under code in the requested language,
+// formatted with args. Unknown codes are returned unchanged.
+func T(lang string, code string, args ...interface{}) string {
+	message, ok := catalog[code]
+	if !ok {
+		return code
+	}
+	text := message.ID
+	if lang == EN && message.EN != "" {
+		text = message.EN
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// Supported reports whether lang is one of the catalog languages.
+func Supported(lang string) bool {
+	return lang == ID || lang == EN
+}
+
+// Negotiate picks the response language from the ?lang= query parameter,
+// falling back to the Accept-Language header and finally to Default.
+func Negotiate(query string, acceptLanguage string) string {
+	if lang := normalize(query); Supported(lang) {
+		return lang
+	}
+
+	type candidate struct {
+		lang    string
+		quality float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if lang := normalize(tag); Supported(lang) && quality > 0 {
+			candidates = append(candidates, candidate{lang: lang, quality: quality})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	if len(candidates) > 0 {
+		return candidates[0].lang
+	}
+	return Default
+}
+
+// normalize reduces a language tag such as "en-US" to its primary subtag.
+func normalize(tag string) string {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	if primary == "in" {
+		// "in" is the deprecated ISO 639 code for Indonesian.
+		return ID
+	}
+	return primary
+}
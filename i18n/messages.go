@@ -0,0 +1,117 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// catalog maps every response code, success and error alike, and the texts
+// of the emails sent to users to their Indonesian and English message.
+var catalog = map[string]Message{
+	// Success messages
+	"REGISTER_SUCCESS":           {ID: "User berhasil registrasi", EN: "User registered successfully"},
+	"LOGIN_SUCCESS":              {ID: "Login berhasil", EN: "Login successful"},
+	"TOKEN_REFRESHED":            {ID: "Token berhasil diperbarui", EN: "Token refreshed successfully"},
+	"LOGOUT_SUCCESS":             {ID: "Logout berhasil", EN: "Logged out successfully"},
+	"WS_TICKET_ISSUED":           {ID: "Tiket WebSocket berhasil dibuat", EN: "WebSocket ticket issued"},
+	"ACCESS_GRANTED":             {ID: "Anda memiliki akses ke endpoint ini", EN: "You have access to this endpoint"},
+	"REVIEW_CREATED":             {ID: "Review berhasil ditambahkan", EN: "Review created successfully"},
+	"REVIEWS_FETCHED":            {ID: "Berhasil mengambil seluruh data review", EN: "Successfully fetched all reviews"},
+	"SCHEDULES_FETCHED":          {ID: "Sukses mengambil seluruh data schedules", EN: "Successfully fetched all schedules"},
+	"STATION_SCHEDULES_FETCHED":  {ID: "Data schedule dengan stasiun id %s berhasil diambil", EN: "Schedules for station id %s fetched successfully"},
+	"TRIP_SCHEDULES_FETCHED":     {ID: "Data schedule dengan stasiun ID: %s dan arah: %s berhasil diambil", EN: "Schedules for station ID: %s and direction: %s fetched successfully"},
+	"STATIONS_FETCHED":           {ID: "Berhasil mengambil semua data stasiun", EN: "Successfully fetched all stations"},
+	"CACHE_STATS_FETCHED":        {ID: "Berhasil mengambil statistik cache", EN: "Successfully fetched cache statistics"},
+	"ALERTS_FETCHED":             {ID: "Berhasil mengambil data gangguan layanan", EN: "Successfully fetched service alerts"},
+	"ALERT_FETCHED":              {ID: "Berhasil mengambil data gangguan layanan", EN: "Successfully fetched the service alert"},
+	"ALERT_CREATED":              {ID: "Pemberitahuan gangguan berhasil dibuat", EN: "Service alert created successfully"},
+	"ALERT_UPDATED":              {ID: "Pemberitahuan gangguan berhasil diperbarui", EN: "Service alert updated successfully"},
+	"ALERT_DELETED":              {ID: "Pemberitahuan gangguan dengan id %s berhasil dihapus", EN: "Service alert with id %s deleted successfully"},
+	"DEPARTURES_FETCHED":         {ID: "Keberangkatan berikutnya dari stasiun id %s berhasil diambil", EN: "Next departures from station id %s fetched successfully"},
+	"WEBHOOKS_FETCHED":           {ID: "Berhasil mengambil data webhook", EN: "Successfully fetched webhooks"},
+	"WEBHOOK_FETCHED":            {ID: "Berhasil mengambil data webhook", EN: "Successfully fetched the webhook"},
+	"WEBHOOK_CREATED":            {ID: "Webhook berhasil didaftarkan", EN: "Webhook registered successfully"},
+	"WEBHOOK_UPDATED":            {ID: "Webhook berhasil diperbarui", EN: "Webhook updated successfully"},
+	"WEBHOOK_DELETED":            {ID: "Webhook dengan id %s berhasil dihapus", EN: "Webhook with id %s deleted successfully"},
+	"WEBHOOK_DELIVERIES_FETCHED": {ID: "Berhasil mengambil riwayat pengiriman webhook", EN: "Successfully fetched webhook deliveries"},
+	"API_KEYS_FETCHED":           {ID: "Berhasil mengambil data API key", EN: "Successfully fetched API keys"},
+	"API_KEY_CREATED":            {ID: "API key berhasil dibuat, simpan key ini karena tidak akan ditampilkan lagi", EN: "API key created, store the key now as it will not be shown again"},
+	"API_KEY_REVOKED":            {ID: "API key dengan id %s berhasil dicabut", EN: "API key with id %s revoked successfully"},
+	"USERS_FETCHED":              {ID: "Berhasil mengambil data user", EN: "Successfully fetched users"},
+	"USER_FETCHED":               {ID: "Berhasil mengambil data user", EN: "Successfully fetched the user"},
+	"USER_ROLE_UPDATED":          {ID: "Role user berhasil diubah", EN: "User role updated successfully"},
+	"USER_DISABLED":              {ID: "Akun user dengan id %s berhasil dinonaktifkan", EN: "User account with id %s disabled successfully"},
+	"USER_ENABLED":               {ID: "Akun user dengan id %s berhasil diaktifkan kembali", EN: "User account with id %s enabled successfully"},
+	"USER_DELETED":               {ID: "User dengan id %s berhasil dihapus", EN: "User with id %s deleted successfully"},
+	"PASSWORD_CHANGED":           {ID: "Password berhasil diubah", EN: "Password changed successfully"},
+	"PASSWORD_RESET_REQUESTED":   {ID: "Jika email tersebut terdaftar, token reset password telah dikirim", EN: "If the email is registered, a password reset token has been sent"},
+	"PASSWORD_RESET":             {ID: "Password berhasil direset, silakan login kembali", EN: "Password reset successfully, please log in again"},
+
+	// Error messages
+	"INTERNAL_ERROR":         {ID: "Terjadi kesalahan pada server", EN: "An internal server error occurred"},
+	"INVALID_REQUEST":        {ID: "Data permintaan tidak valid", EN: "The request data is invalid"},
+	"VALIDATION_FAILED":      {ID: "Data permintaan tidak lolos validasi", EN: "The request data failed validation"},
+	"INVALID_PARAMETER":      {ID: "Parameter %s tidak valid", EN: "Parameter %s is invalid"},
+	"ROUTE_NOT_FOUND":        {ID: "Endpoint tidak ditemukan", EN: "Endpoint not found"},
+	"UNSUPPORTED_FORMAT":     {ID: "Format %q tidak didukung, gunakan json, ics atau csv", EN: "Format %q is not supported, use json, ics or csv"},
+	"MISSING_TOKEN":          {ID: "Token tidak ditemukan", EN: "Missing token"},
+	"INVALID_TOKEN":          {ID: "Token tidak valid", EN: "Invalid token"},
+	"TOKEN_REVOKED":          {ID: "Token sudah dicabut, silakan login kembali", EN: "Token has been revoked, please log in again"},
+	"INVALID_REFRESH_TOKEN":  {ID: "Refresh token tidak valid atau sudah kedaluwarsa", EN: "Invalid or expired refresh token"},
+	"INVALID_API_KEY":        {ID: "API key tidak valid, kedaluwarsa, atau sudah dicabut", EN: "Invalid, expired or revoked API key"},
+	"INSUFFICIENT_SCOPE":     {ID: "API key tidak memiliki scope %s", EN: "API key lacks the %s scope"},
+	"INVALID_CREDENTIALS":    {ID: "Username atau Password salah", EN: "Incorrect username or password"},
+	"UNAUTHORIZED":           {ID: "Akses tidak diizinkan", EN: "Unauthorized"},
+	"USER_NOT_FOUND":         {ID: "User tidak ditemukan", EN: "User not found"},
+	"ACCOUNT_DISABLED":       {ID: "Akun ini telah dinonaktifkan", EN: "This account has been disabled"},
+	"CANNOT_MODIFY_SELF":     {ID: "Anda tidak dapat mengubah role, menonaktifkan, atau menghapus akun Anda sendiri", EN: "You cannot change the role of, disable or delete your own account"},
+	"USERNAME_TAKEN":         {ID: "Username %s sudah digunakan", EN: "Username %s is already taken"},
+	"EMAIL_TAKEN":            {ID: "Email sudah digunakan", EN: "Email is already taken"},
+	"INCORRECT_PASSWORD":     {ID: "Password saat ini salah", EN: "The current password is incorrect"},
+	"INVALID_RESET_TOKEN":    {ID: "Token reset password tidak valid, kedaluwarsa, atau sudah digunakan", EN: "Invalid, expired or already used password reset token"},
+	"REGISTRATION_FAILED":    {ID: "Registrasi user dengan username %s gagal", EN: "Failed to register user %s"},
+	"INVALID_STATION_ID":     {ID: "Stasiun id %s tidak valid", EN: "Station id %s is invalid"},
+	"STATION_NOT_FOUND":      {ID: "Stasiun dengan id %s tidak ditemukan", EN: "Station with id %s not found"},
+	"SCHEDULE_NOT_FOUND":     {ID: "Data schedule dengan stasiun ID: %s dan arah %s tidak ditemukan", EN: "No schedules found for station ID: %s and direction %s"},
+	"FORBIDDEN":              {ID: "Anda tidak memiliki izin untuk mengakses endpoint ini", EN: "You are not allowed to access this endpoint"},
+	"ALERT_NOT_FOUND":        {ID: "Pemberitahuan gangguan dengan id %s tidak ditemukan", EN: "Service alert with id %s not found"},
+	"WEBHOOK_NOT_FOUND":      {ID: "Webhook dengan id %s tidak ditemukan", EN: "Webhook with id %s not found"},
+	"API_KEY_NOT_FOUND":      {ID: "API key dengan id %s tidak ditemukan", EN: "API key with id %s not found"},
+	"TOO_MANY_SUBSCRIPTIONS": {ID: "Maksimal %d langganan per koneksi", EN: "At most %d subscriptions per connection"},
+	"TOO_MANY_REQUESTS":      {ID: "Terlalu banyak permintaan, coba lagi nanti", EN: "Too many requests, try again later"},
+
+	// Mail
+	"PASSWORD_RESET_MAIL_SUBJECT": {ID: "Reset password MRT-api", EN: "Reset your MRT-api password"},
+	"PASSWORD_RESET_MAIL_BODY":    {ID: "Halo %s,\n\nGunakan token berikut dalam %d menit untuk memilih password baru:\n\n%s\n\nJika Anda tidak memintanya, abaikan email ini; password Anda tidak berubah.", EN: "Hello %s,\n\nUse the following token within %d minutes to choose a new password:\n\n%s\n\nIf you did not ask for it, ignore this email; your password stays the same."},
+}
+
+// validationMessages maps validator tags to field error messages. The first
+// verb is the field name and the second, when present, the tag parameter.
+var validationMessages = map[string]Message{
+	"required": {ID: "%s wajib diisi", EN: "%s is required"},
+	"min":      {ID: "%s minimal %s", EN: "%s must be at least %s"},
+	"max":      {ID: "%s maksimal %s", EN: "%s must be at most %s"},
+	"gte":      {ID: "%s harus lebih besar atau sama dengan %s", EN: "%s must be greater than or equal to %s"},
+	"lte":      {ID: "%s harus lebih kecil atau sama dengan %s", EN: "%s must be less than or equal to %s"},
+	"oneof":    {ID: "%s harus salah satu dari: %s", EN: "%s must be one of: %s"},
+	"email":    {ID: "%s harus berupa alamat email yang valid", EN: "%s must be a valid email address"},
+	"username": {ID: "%s hanya boleh berisi huruf, angka, '.', '_' dan '-', diawali huruf atau angka", EN: "%s may only contain letters, digits, '.', '_' and '-', starting with a letter or digit"},
+	"password": {ID: "%s harus berisi huruf dan angka, maksimal 72 byte", EN: "%s must contain letters and digits and be at most 72 bytes"},
+}
+
+// Validation returns the localized message for a field that failed the given
+// validator tag.
+func Validation(lang string, tag string, field string, param string) string {
+	message, ok := validationMessages[tag]
+	if !ok {
+		message = Message{ID: "%s tidak valid", EN: "%s is invalid"}
+	}
+	text := message.ID
+	if lang == EN {
+		text = message.EN
+	}
+	if strings.Count(text, "%s") > 1 {
+		return fmt.Sprintf(text, field, param)
+	}
+	return fmt.Sprintf(text, field)
+}
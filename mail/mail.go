@@ -0,0 +1,50 @@
+// Package mail sends email to users through a replaceable Sender.
+package mail
+
+import (
+	"log"
+	"sync"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages. Implementations for real mail providers can be
+// installed with SetSender.
+type Sender interface {
+	Send(msg Message) error
+}
+
+var (
+	senderMu sync.RWMutex
+	sender   Sender = Log{}
+)
+
+// SetSender replaces the sender used by Send. It is meant to be called once
+// at startup, before requests are served.
+func SetSender(s Sender) {
+	senderMu.Lock()
+	defer senderMu.Unlock()
+	sender = s
+}
+
+// Send delivers a message with the configured sender.
+func Send(msg Message) error {
+	senderMu.RLock()
+	s := sender
+	senderMu.RUnlock()
+	return s.Send(msg)
+}
+
+// Log is the default sender. It writes messages to the server log instead
+// of sending them, which is only suitable for development.
+type Log struct{}
+
+func (Log) Send(msg Message) error {
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,26 @@
+package mail
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Outbox stores messages in the mail_outbox table instead of sending them,
+// so tests and developers can read them back.
+type Outbox struct {
+	db *sql.DB
+}
+
+// NewOutbox returns a sender writing to the mail_outbox table of db.
+func NewOutbox(db *sql.DB) *Outbox {
+	return &Outbox{db: db}
+}
+
+func (o *Outbox) Send(msg Message) error {
+	_, err := o.db.Exec("INSERT INTO mail_outbox (recipient, subject, body) VALUES ($1, $2, $3)",
+		msg.To, msg.Subject, msg.Body)
+	if err != nil {
+		return fmt.Errorf("error writing mail to outbox: %v", err)
+	}
+	return nil
+}
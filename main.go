@@ -3,8 +3,8 @@ package main
 import (
 	"fmt"
 	"log"
-	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,10 +12,18 @@ import (
 	"github.com/robfig/cron/v3"
 
 	_ "time/tzdata"
-	"web-scrapper/controllers"
+	"web-scrapper/alerts"
+	"web-scrapper/auth"
+	"web-scrapper/cache"
 	"web-scrapper/database"
+	"web-scrapper/mail"
 	"web-scrapper/middleware"
+	"web-scrapper/models"
+	"web-scrapper/realtime"
+	"web-scrapper/routes"
 	"web-scrapper/scraping"
+	"web-scrapper/timetable"
+	"web-scrapper/webhooks"
 
 	_ "github.com/lib/pq"
 )
@@ -31,6 +39,11 @@ func init() {
 		fmt.Println("Successfully read environment file")
 	}
 
+	// Load the JWT signing keys
+	if err := auth.Load(); err != nil {
+		log.Fatalf("Error loading JWT keys: %v", err)
+	}
+
 	// Initialize database connection
 	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s", os.Getenv("PGUSER"), os.Getenv("PGPASSWORD"), os.Getenv("PGHOST"), os.Getenv("PGPORT"), os.Getenv("PGDATABASE"))
 	err = database.InitDB(dsn)
@@ -43,6 +56,33 @@ func init() {
 		log.Fatalf("Error initializing database tables: %v", err)
 	}
 
+	// Load the active timetable into memory; every read endpoint serves from it
+	if _, err := timetable.Refresh(database.GetDB()); err != nil {
+		log.Fatalf("Error loading timetable: %v", err)
+	}
+
+	// Load the service alerts and pick up changes made on other replicas
+	if err := alerts.Load(database.GetDB()); err != nil {
+		log.Fatalf("Error loading service alerts: %v", err)
+	}
+	go alerts.Watch(database.GetDB(), time.Minute)
+
+	// Send queued webhook deliveries and notify partners of new alerts
+	go webhooks.Dispatch(database.GetDB(), 10*time.Second)
+	alerts.OnCreate(func(alert models.ServiceAlert) {
+		publishEvent(models.EventAlertCreated, alert)
+	})
+
+	// Share the cache between replicas when Redis is configured
+	if err := initCache(); err != nil {
+		log.Fatalf("Error initializing cache: %v", err)
+	}
+
+	// Choose where password reset mail goes
+	if err := initMail(); err != nil {
+		log.Fatalf("Error initializing mail: %v", err)
+	}
+
 	// Ensure the /tmp directory exists
 	ensureDataDirectory()
 
@@ -53,28 +93,19 @@ func init() {
 func main() {
 	// Set up Gin router
 	router := gin.Default()
+	router.Use(middleware.RequestID())     // Tag every request with an ID
+	router.Use(middleware.Language())      // Negotiate the message language
 	router.Use(middleware.CORSMidleware()) // Apply CORS middleware
-	router.POST("/api/v1/register", controllers.RegisterUser)
-	router.POST("/api/v1/login", controllers.LoginUser)
-	router.GET("/api/v1/reviews", controllers.GetAllReviews)
-	protected := router.Group("/api")
-	protected.Use(middleware.JWTAuthMiddleware())
-	{
-		//these are for the website
-		protected.GET("/secure_endpoint", secureEndpointHandler)
-		protected.GET("/stasiun", controllers.GetAllStasiun)
-		protected.GET("/schedules", controllers.GetAllSchedules)
-		protected.GET("/schedules/:id", controllers.GetSchedulesByID)
-		protected.GET("/schedules/:id/:arah", controllers.GetSchedulesByIDAndTrip)
-
-		//these below are for sanber
-
-		protected.GET("/v1/stasiun", controllers.GetAllStasiunV1)
-		protected.GET("/v1/schedules", controllers.GetAllSchedulesV1)
-		protected.GET("/v1/schedules/:id", controllers.GetSchedulesByStationIDV1)
-		protected.GET("/v1/schedules/:id/:arah", controllers.GetSchedulesByIDAndTripV1)
-		protected.POST("/v1/reviews", controllers.CreateReview)
-	}
+	router.Use(middleware.Compress())      // Negotiate gzip or brotli responses
+
+	// Fan departures, timetable changes and alerts out to WebSocket clients,
+	// accepting browser pages from WS_ALLOWED_ORIGINS
+	hub := realtime.NewHub(strings.Split(os.Getenv("WS_ALLOWED_ORIGINS"), ",")...)
+	go hub.Run()
+	alerts.OnCreate(func(alert models.ServiceAlert) {
+		hub.PublishAlert(alert, alert.StationIDs...)
+	})
+	routes.Register(router, hub)
 
 	// Serve HTTP requests with Gin router
 	port := os.Getenv("PORT")
@@ -118,6 +149,7 @@ func startCronScheduler() {
 		// Cron job to run daily at midnight
 		if err := runScrapingTask(); err != nil {
 			log.Printf("Error running scraping task: %v", err)
+			publishEvent(models.EventScrapeFailed, gin.H{"task": "timetable", "error": err.Error()})
 			return
 		}
 	})
@@ -126,6 +158,31 @@ func startCronScheduler() {
 		log.Fatalf("Error adding cron job: %v", cronErr)
 	}
 
+	// Forget expired refresh tokens and revocations daily
+	if _, err := c.AddFunc("30 3 * * *", func() {
+		if err := auth.PurgeExpired(database.GetDB()); err != nil {
+			log.Println(err)
+		}
+	}); err != nil {
+		log.Fatalf("Error adding token cleanup cron job: %v", err)
+	}
+
+	// Import operator announcements on their own schedule; "off" disables it
+	alertsSchedule := os.Getenv("ALERTS_SCRAPE_SCHEDULE")
+	if alertsSchedule == "" {
+		alertsSchedule = "*/30 * * * *"
+	}
+	if alertsSchedule != "off" {
+		if _, err := c.AddFunc(alertsSchedule, func() {
+			if err := runAlertScrapingTask(); err != nil {
+				log.Printf("Error running alert scraping task: %v", err)
+				publishEvent(models.EventScrapeFailed, gin.H{"task": "announcements", "error": err.Error()})
+			}
+		}); err != nil {
+			log.Fatalf("Error adding alert scraping cron job: %v", err)
+		}
+	}
+
 	// Start the cron scheduler
 	c.Start()
 	log.Println("Cron job started. Waiting for signals...")
@@ -166,9 +223,97 @@ func runScrapingTask() error {
 		return fmt.Errorf("error inserting data: %v", err)
 	}
 	log.Println("Data inserted successfully!")
+
+	// Swap in the new timetable index and publish its version so clients revalidate their caches
+	previous := timetable.Current()
+	version, err := timetable.Refresh(database.GetDB())
+	if err != nil {
+		return fmt.Errorf("error refreshing timetable version: %v", err)
+	}
+	if version.ETag != previous.ETag {
+		publishEvent(models.EventTimetableUpdated, gin.H{"version": version.ETag, "last_modified": version.LastModified})
+	}
+
+	// Drop every cached response built from the previous timetable
+	if err := cache.Purge(); err != nil {
+		return fmt.Errorf("error purging cache: %v", err)
+	}
 	return nil
 }
 
+// publishEvent queues a webhook event, logging rather than failing the caller.
+func publishEvent(event string, data interface{}) {
+	if err := webhooks.Publish(database.GetDB(), event, data); err != nil {
+		log.Println(err)
+	}
+}
+
+// runAlertScrapingTask imports the disruption notices on the operator's news
+// page at ALERTS_SOURCE_URL as service alerts.
+func runAlertScrapingTask() error {
+	sourceURL := os.Getenv("ALERTS_SOURCE_URL")
+	if sourceURL == "" {
+		sourceURL = scraping.DefaultAnnouncementsURL
+	}
+
+	announcements, err := scraping.ScrapeAnnouncements(sourceURL)
+	if err != nil {
+		return fmt.Errorf("error scraping announcements: %v", err)
+	}
+
+	imported := 0
+	for _, announcement := range announcements {
+		if !announcement.IsDisruption() {
+			continue
+		}
+		created, err := alerts.Import(database.GetDB(), announcement.AlertInput(), announcement.URL, announcement.ContentHash())
+		if err != nil {
+			return err
+		}
+		if created {
+			imported++
+		}
+	}
+	log.Printf("Imported %d new alerts from %d announcements", imported, len(announcements))
+	return nil
+}
+
+// initCache selects the cache backend from CACHE_BACKEND. With "redis" every
+// replica shares one cache at REDIS_URL and reloads the timetable version when
+// another replica's scrape purges it.
+func initCache() error {
+	switch os.Getenv("CACHE_BACKEND") {
+	case "", "memory":
+		return nil
+	case "redis":
+		backend, err := cache.NewRedis(os.Getenv("REDIS_URL"))
+		if err != nil {
+			return err
+		}
+		cache.OnRemotePurge(func() {
+			if _, err := timetable.Refresh(database.GetDB()); err != nil {
+				log.Printf("Error refreshing timetable version: %v", err)
+			}
+		})
+		cache.SetBackend(backend)
+		return nil
+	}
+	return fmt.Errorf("unknown CACHE_BACKEND %q", os.Getenv("CACHE_BACKEND"))
+}
+
+// initMail selects the mail sender from MAIL_SENDER. "log" writes messages
+// to the server log and "outbox" stores them in the mail_outbox table.
+func initMail() error {
+	switch os.Getenv("MAIL_SENDER") {
+	case "", "log":
+		return nil
+	case "outbox":
+		mail.SetSender(mail.NewOutbox(database.GetDB()))
+		return nil
+	}
+	return fmt.Errorf("unknown MAIL_SENDER %q", os.Getenv("MAIL_SENDER"))
+}
+
 func ensureDataDirectory() {
 	dataDir := "/tmp"
 	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
@@ -183,7 +328,3 @@ func ensureDataDirectory() {
 		log.Printf("Directory %s already exists.", dataDir)
 	}
 }
-
-func secureEndpointHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "You have access to this endpoint"})
-}
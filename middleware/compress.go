@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"web-scrapper/timetable"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// compressMinSize skips bodies too small to benefit from compression.
+	compressMinSize = 1024
+	// maxPrecompressed bounds the number of payloads kept per timetable version.
+	maxPrecompressed = 256
+)
+
+var compressibleTypes = []string{
+	"application/json",
+	"application/javascript",
+	"application/pdf",
+	"text/",
+}
+
+// precompressedPayloads keeps compressed timetable responses, keyed by ETag
+// and encoding, for the lifetime of one timetable version.
+var precompressedPayloads = struct {
+	sync.Mutex
+	version string
+	entries map[string][]byte
+}{}
+
+// Compress negotiates gzip or brotli through Accept-Encoding and compresses
+// response bodies of at least compressMinSize bytes. Responses carrying an
+// ETag from TimetableCache are compressed once per timetable version at a
+// higher level and then served from memory.
+func Compress() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Streams and protocol upgrades must reach the client unbuffered.
+		if c.GetHeader("Upgrade") != "" || strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+			c.Next()
+			return
+		}
+
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		original := c.Writer
+		writer := &bufferedWriter{ResponseWriter: original, status: original.Status()}
+		c.Writer = writer
+		defer func() { c.Writer = original }()
+
+		c.Next()
+		if writer.streaming {
+			return
+		}
+
+		header := original.Header()
+		body := writer.body.Bytes()
+		compressible := writer.status == http.StatusOK &&
+			len(body) >= compressMinSize &&
+			header.Get("Content-Encoding") == "" &&
+			header.Get("Content-Range") == "" &&
+			isCompressible(header.Get("Content-Type"))
+
+		if compressible {
+			header.Add("Vary", "Accept-Encoding")
+		}
+		if !compressible || encoding == "" {
+			original.WriteHeader(writer.status)
+			original.WriteHeaderNow()
+			original.Write(body)
+			return
+		}
+
+		compressed, err := compressBody(body, encoding, header.Get("ETag"))
+		if err != nil {
+			log.Printf("Error compressing response: %v", err)
+			original.WriteHeader(writer.status)
+			original.WriteHeaderNow()
+			original.Write(body)
+			return
+		}
+
+		header.Set("Content-Encoding", encoding)
+		header.Set("Content-Length", strconv.Itoa(len(compressed)))
+		original.WriteHeader(writer.status)
+		original.WriteHeaderNow()
+		original.Write(compressed)
+	}
+}
+
+// compressBody compresses body, reusing the payload compressed for the same
+// ETag and encoding when the response belongs to the current timetable
+// version. TimetableCache derives the ETag from the timetable version and
+// the request variant, and has the envelope leave out the request_id, so
+// the stored payload is the same for every client of that variant.
+func compressBody(body []byte, encoding string, etag string) ([]byte, error) {
+	version := timetable.Current()
+	if etag == "" || version.IsZero() || !strings.Contains(etag, version.ETag) {
+		return encode(body, encoding, false)
+	}
+
+	key := etag + "|" + encoding
+	precompressedPayloads.Lock()
+	if precompressedPayloads.version != version.ETag {
+		precompressedPayloads.version = version.ETag
+		precompressedPayloads.entries = make(map[string][]byte)
+	}
+	payload, found := precompressedPayloads.entries[key]
+	precompressedPayloads.Unlock()
+	if found {
+		return payload, nil
+	}
+
+	payload, err := encode(body, encoding, true)
+	if err != nil {
+		return nil, err
+	}
+
+	precompressedPayloads.Lock()
+	if precompressedPayloads.version == version.ETag && len(precompressedPayloads.entries) < maxPrecompressed {
+		precompressedPayloads.entries[key] = payload
+	}
+	precompressedPayloads.Unlock()
+	return payload, nil
+}
+
+// encode compresses body. Payloads compressed once per timetable version use
+// the best ratio; everything else favours speed.
+func encode(body []byte, encoding string, best bool) ([]byte, error) {
+	var buf bytes.Buffer
+	switch encoding {
+	case "br":
+		quality := 5
+		if best {
+			quality = brotli.BestCompression
+		}
+		writer := brotli.NewWriterLevel(&buf, quality)
+		if _, err := writer.Write(body); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+	case "gzip":
+		level := gzip.DefaultCompression
+		if best {
+			level = gzip.BestCompression
+		}
+		writer, err := gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(body); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// negotiateEncoding picks br over gzip among the encodings the client accepts
+// with a non-zero quality, or "" to send the body uncompressed.
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if value, err := strconv.ParseFloat(q, 64); err == nil && value == 0 {
+				continue
+			}
+		}
+		accepted[name] = true
+	}
+
+	switch {
+	case accepted["br"]:
+		return "br"
+	case accepted["gzip"], accepted["*"]:
+		return "gzip"
+	}
+	return ""
+}
+
+func isCompressible(contentType string) bool {
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedWriter holds the status and body written by the handlers so the
+// compression decision can be made once the whole response is known. A
+// handler that flushes, such as an event stream, switches it to writing
+// through uncompressed.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	status    int
+	written   bool
+	streaming bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+	w.written = true
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+// WriteHeaderNow is deferred until Compress writes the final response.
+func (w *bufferedWriter) WriteHeaderNow() {
+	if w.streaming {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	if w.streaming {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	if w.streaming {
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.body.WriteString(s)
+}
+
+// Flush sends what has been buffered so far and every later write straight
+// to the client.
+func (w *bufferedWriter) Flush() {
+	if !w.streaming {
+		w.streaming = true
+		w.ResponseWriter.WriteHeader(w.status)
+		w.ResponseWriter.WriteHeaderNow()
+		w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if w.streaming {
+		return w.ResponseWriter.Size()
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
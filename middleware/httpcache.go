@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"web-scrapper/i18n"
+	"web-scrapper/response"
+	"web-scrapper/timetable"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimetableCache makes timetable responses cacheable, by clients and CDNs,
+// until the next scrape. It emits an ETag derived from the active timetable
+// version and the request variant, a Last-Modified of the latest change to
+// either, and answers conditional requests with 304 Not Modified. The
+// responses hold nothing but the timetable: live service alerts are served
+// by the alerts and departures endpoints.
+func TimetableCache() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version := timetable.Current()
+		if version.IsZero() || (c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead) {
+			c.Next()
+			return
+		}
+
+		now := time.Now()
+		etag := fmt.Sprintf(`W/"%s-%s"`, version.ETag, requestVariant(c, now))
+		maxAge := int(timetable.NextRefresh(now).Sub(now).Seconds())
+
+		// Some endpoints return the timetable of the current day type, so a
+		// client revalidating by date alone must see the day type change too.
+		lastModified := version.LastModified
+		if since := timetable.DayTypeSince(now).UTC(); since.After(lastModified) {
+			lastModified = since
+		}
+
+		header := c.Writer.Header()
+		header.Set("ETag", etag)
+		header.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		// The timetable is the same for every client, but only clients that
+		// passed JWTAuthMiddleware reach this, so shared caches key it by
+		// credentials too.
+		header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d, s-maxage=%d", maxAge, maxAge))
+		header.Add("Vary", "Accept")
+		header.Add("Vary", "Authorization")
+		header.Add("Vary", "X-API-Key")
+
+		if notModified(c.Request, etag, lastModified) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+
+		// Compress stores the body once per timetable version, so it must not
+		// carry anything specific to this request.
+		response.SharedBody(c)
+		c.Writer = &cacheableWriter{ResponseWriter: c.Writer}
+		c.Next()
+	}
+}
+
+// requestVariant distinguishes representations of the same timetable
+// version: the URL with its query, the negotiated format and language, and
+// the day type, which selects the timetable some endpoints return.
+func requestVariant(c *gin.Context, now time.Time) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n%s", c.Request.URL.RequestURI(), c.GetHeader("Accept"), c.GetString(i18n.ContextKey), timetable.DayType(now))
+	return hex.EncodeToString(hash.Sum(nil))[:12]
+}
+
+// notModified evaluates If-None-Match, falling back to If-Modified-Since
+// only when no entity tag was sent, as RFC 9110 requires.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if since := r.Header.Get("If-Modified-Since"); since != "" {
+		t, err := http.ParseTime(since)
+		return err == nil && !lastModified.After(t)
+	}
+	return false
+}
+
+// cacheableWriter drops the caching headers when the handler answers with an
+// error, so clients never keep a failure until the next scrape.
+type cacheableWriter struct {
+	gin.ResponseWriter
+}
+
+func (w *cacheableWriter) WriteHeader(code int) {
+	if code < 200 || code >= 300 {
+		header := w.Header()
+		header.Del("ETag")
+		header.Del("Last-Modified")
+		header.Set("Cache-Control", "no-store")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
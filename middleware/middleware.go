@@ -1,24 +1,59 @@
 package middleware
 
 import (
-	"fmt"
+	"crypto/rand"
+	"encoding/hex"
 	"log"
 	"net/http"
+	"regexp"
 	"strings"
+	"web-scrapper/auth"
 	"web-scrapper/database"
+	"web-scrapper/i18n"
 	"web-scrapper/models"
+	"web-scrapper/response"
 
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
-var jwtKey = []byte("your_secret_key")
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID tags every request with an ID, reusing a well-formed X-Request-ID
+// sent by the client, and echoes it in the response header and envelope.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if !requestIDPattern.MatchString(requestID) {
+			buf := make([]byte, 16)
+			if _, err := rand.Read(buf); err != nil {
+				log.Printf("Error generating request id: %v", err)
+			}
+			requestID = hex.EncodeToString(buf)
+		}
+		c.Set(response.RequestIDKey, requestID)
+		c.Writer.Header().Set("X-Request-ID", requestID)
+		c.Next()
+	}
+}
+
+// Language selects the message language from ?lang= or Accept-Language and
+// announces it in the Content-Language header.
+func Language() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := i18n.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language"))
+		c.Set(i18n.ContextKey, lang)
+		c.Writer.Header().Set("Content-Language", lang)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
 
 func CORSMidleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID, Accept-Language, X-API-Key")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, X-Data-Source, ETag, Content-Language")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -30,56 +65,168 @@ func CORSMidleware() gin.HandlerFunc {
 	}
 }
 
+// JWTAuthMiddleware authenticates users with a Bearer JWT and machine
+// clients with an X-API-Key header. API keys have no user or role; the
+// routes they may call are marked with RequireScope.
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			key, err := auth.AuthenticateAPIKey(database.GetDB(), apiKey)
+			if err == auth.ErrInvalidAPIKey {
+				response.Abort(c, http.StatusUnauthorized, response.CodeInvalidAPIKey)
+				return
+			}
+			if err != nil {
+				log.Println(err)
+				response.Abort(c, http.StatusInternalServerError, response.CodeInternal)
+				return
+			}
+			c.Set("api_key_id", key.ID)
+			c.Set("scopes", key.Scopes)
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
-			c.Abort()
+			response.Abort(c, http.StatusUnauthorized, response.CodeMissingToken)
 			return
 		}
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token format"})
-			c.Abort()
+			response.Abort(c, http.StatusUnauthorized, response.CodeInvalidToken)
 			return
 		}
 
-		claims := &models.Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return jwtKey, nil
-		})
+		claims, err := auth.Verify(tokenString)
 		if err != nil {
-			if err == jwt.ErrSignatureInvalid {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token signature"})
-				c.Abort()
-				return
-			}
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
+			response.Abort(c, http.StatusUnauthorized, response.CodeInvalidToken)
+			return
+		}
+		if authenticateUser(c, claims) {
+			c.Next()
+		}
+	}
+}
+
+// WebSocketAuth authenticates WebSocket requests. Browsers cannot set
+// headers on them, so they send a ticket from auth.IssueTicket in ?ticket=,
+// which works once. Other clients authenticate like with
+// JWTAuthMiddleware.
+func WebSocketAuth() gin.HandlerFunc {
+	headers := JWTAuthMiddleware()
+	return func(c *gin.Context) {
+		ticket := c.Query("ticket")
+		if ticket == "" {
+			headers(c)
 			return
 		}
-		if !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
+
+		claims, err := auth.VerifyTicket(ticket)
+		if err != nil {
+			response.Abort(c, http.StatusUnauthorized, response.CodeInvalidToken)
 			return
 		}
-		var exists bool
-		err = database.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND username = $2)", claims.UserID, claims.Username).Scan(&exists)
-		if err != nil || !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "user does not exist"})
-			c.Abort()
+		if !authenticateUser(c, claims) {
 			return
 		}
+		if err := auth.RevokeAccessToken(database.GetDB(), claims); err != nil {
+			log.Println(err)
+			response.Abort(c, http.StatusInternalServerError, response.CodeInternal)
+			return
+		}
+		c.Next()
+	}
+}
+
+// authenticateUser checks that the token behind claims was not revoked and
+// that its user may still use it, then stores the user in the context. It
+// aborts the request itself and reports false otherwise.
+func authenticateUser(c *gin.Context, claims *models.Claims) bool {
+	revoked, err := auth.IsRevoked(database.GetDB(), claims.Id)
+	if err != nil {
+		log.Printf("Error checking token revocation: %v", err)
+		response.Abort(c, http.StatusInternalServerError, response.CodeInternal)
+		return false
+	}
+	if revoked {
+		response.Abort(c, http.StatusUnauthorized, response.CodeTokenRevoked)
+		return false
+	}
+	// The role is read from the database so that a role change applies
+	// without waiting for the token to expire.
+	var role string
+	var disabled bool
+	var generation int
+	err = database.DB.QueryRow("SELECT COALESCE(role, ''), disabled_at IS NOT NULL, token_generation FROM users WHERE id = $1 AND username = $2",
+		claims.UserID, claims.Username).Scan(&role, &disabled, &generation)
+	if err != nil {
+		response.Abort(c, http.StatusUnauthorized, response.CodeUserNotFound)
+		return false
+	}
+	if disabled {
+		response.Abort(c, http.StatusForbidden, response.CodeAccountDisabled)
+		return false
+	}
+	// A password change ends the sessions opened before it.
+	if claims.Generation != generation {
+		response.Abort(c, http.StatusUnauthorized, response.CodeTokenRevoked)
+		return false
+	}
+
+	log.Printf("JWT Claims - Username: %s, UserID: %d, Role:%s", claims.Username, claims.UserID, role)
+	c.Set("username", claims.Username)
+	c.Set("user_id", claims.UserID)
+	c.Set("role", role)
+	c.Set("claims", claims)
+	return true
+}
 
-		log.Printf("JWT Claims - Username: %s, UserID: %d, Role:%s", claims.Username, claims.UserID, claims.Role)
-		c.Set("username", claims.Username)
-		c.Set("user_id", claims.UserID)
-		c.Set("role", claims.Role)
-		c.Set("claims", claims)
+// RequireScope lets API keys through only when they were granted scope.
+// Requests authenticated with a JWT are not affected.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isAPIKey := c.Get("api_key_id"); isAPIKey {
+			granted := false
+			for _, s := range c.GetStringSlice("scopes") {
+				if s == scope {
+					granted = true
+					break
+				}
+			}
+			if !granted {
+				response.Abort(c, http.StatusForbidden, response.CodeInsufficientScope, scope)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireRole lets only users with one of the given roles through. It must
+// run after JWTAuthMiddleware, which puts the role in the context. API keys
+// have no role and are refused.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		response.Abort(c, http.StatusForbidden, response.CodeForbidden)
+	}
+}
+
+// RequirePermission lets only users whose role grants permission through.
+// It must run after JWTAuthMiddleware. API keys have no role and are refused.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.HasPermission(c.GetString("role"), permission) {
+			response.Abort(c, http.StatusForbidden, response.CodeForbidden)
+			return
+		}
 		c.Next()
 	}
 }
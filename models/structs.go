@@ -1,11 +1,19 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
+// Day types of the timetable. The scraper stores weekend departures with an
+// empty stasiun_name, which is how the two are told apart.
+const (
+	DayWeekday = "weekday"
+	DayWeekend = "weekend"
+)
+
 type Schedule struct {
 	ID          int    `json:"id"`
 	StasiunID   int    `json:"station_id"`
@@ -14,25 +22,146 @@ type Schedule struct {
 	Jadwal      string `json:"jadwal"`
 }
 
+// DayType reports whether the schedule belongs to the weekday or weekend timetable.
+func (s Schedule) DayType() string {
+	if s.StasiunName == "" {
+		return DayWeekend
+	}
+	return DayWeekday
+}
+
 type Stasiun struct {
 	StasiunID   int    `json:"id"`
 	StasiunName string `json:"stasiun_name"`
 }
 
+// User is a user account. Password holds the bcrypt hash and is never sent
+// to clients.
 type User struct {
 	ID       int    `json:"id" gorm:"primary_key"`
 	Username string `json:"username" gorm:"unique"`
-	Password string `json:"password"`
+	Email    string `json:"email,omitempty"`
+	Password string `json:"-"`
 	Role     string `json:"role"`
+	// TokenGeneration is bumped by every password change; access tokens
+	// carry the generation they were issued in.
+	TokenGeneration int `json:"-"`
+}
+
+// RegisterInput is the body accepted when registering. Users always start
+// with the user role; a role in the body is ignored. The email is optional
+// and only used to reset a forgotten password.
+type RegisterInput struct {
+	Username string `json:"username" binding:"required,min=3,max=32,username"`
+	Email    string `json:"email,omitempty" binding:"omitempty,email,max=254"`
+	Password string `json:"password" binding:"required,min=8,max=72,password"`
+}
+
+// LoginInput is the body accepted when logging in. It is not validated
+// beyond presence so that accounts older than the registration rules can
+// still log in.
+type LoginInput struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// PasswordChangeInput is the body accepted when users change their own
+// password.
+type PasswordChangeInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=72,password"`
+}
+
+// PasswordForgotInput is the body accepted when requesting a password reset
+// token by email.
+type PasswordForgotInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// PasswordResetInput is the body accepted when setting a new password with
+// a reset token.
+type PasswordResetInput struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=72,password"`
+}
+
+// UserAccount is a user as seen by admins managing accounts.
+type UserAccount struct {
+	ID          int        `json:"id"`
+	Username    string     `json:"username"`
+	Email       string     `json:"email,omitempty"`
+	Role        string     `json:"role"`
+	Disabled    bool       `json:"disabled"`
+	DisabledAt  *time.Time `json:"disabled_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ReviewCount int        `json:"review_count"`
+}
+
+// RoleInput is the body accepted when an admin changes a user's role.
+type RoleInput struct {
+	Role string `json:"role" binding:"required"`
 }
 
 type Claims struct {
-	Username string `json:"username"`
-	UserID   int    `json:"user_id"`
-	Role     string `json:"role"`
+	Username   string `json:"username"`
+	UserID     int    `json:"user_id"`
+	Role       string `json:"role"`
+	Generation int    `json:"gen"`
 	jwt.StandardClaims
 }
 
+// TokenPair is returned on login and refresh. AccessToken is a JWT valid for
+// ExpiresIn seconds and RefreshToken an opaque token exchanged for a new pair
+// at /api/v1/token/refresh.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// RefreshRequest is the body of the refresh and logout endpoints. All ends
+// every session of the user on logout.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	All          bool   `json:"all"`
+}
+
+// WebSocketTicket lets a browser open /api/v1/ws, passed in ?ticket= within
+// ExpiresIn seconds. It works once.
+type WebSocketTicket struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// Scopes an API key can be granted.
+const (
+	ScopeTimetableRead = "timetable:read"
+)
+
+// APIKey is the credential of a machine client, sent in the X-API-Key header.
+// Key is only returned when the key is created; Prefix identifies it later.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Key        string     `json:"key,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  *int       `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// APIKeyInput is the body accepted when an admin creates an API key. A nil
+// ExpiresAt never expires.
+type APIKeyInput struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=timetable:read"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
 type Review struct {
 	ID        int       `json:"id"`
 	UserID    int       `json:"user_id"`
@@ -40,3 +169,105 @@ type Review struct {
 	Comment   string    `json:"comment"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+// Departure is an upcoming train leaving a station, computed from the timetable.
+type Departure struct {
+	StationID    int       `json:"station_id"`
+	Arah         string    `json:"arah"`
+	Jadwal       string    `json:"jadwal"`
+	DayType      string    `json:"day_type"`
+	DepartsAt    time.Time `json:"departs_at"`
+	MinutesUntil int       `json:"minutes_until"`
+}
+
+// Severities of a service alert, from least to most disruptive.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// ServiceAlert is a disruption notice. An empty StationIDs applies to the
+// whole line and an empty Directions to both directions. Message is the text
+// in the language of the request and is only set in responses. SourceURL is
+// set on alerts imported from the operator website, whose MessageEN is empty
+// because the operator only publishes in Indonesian.
+type ServiceAlert struct {
+	ID         int        `json:"id"`
+	StationIDs []int      `json:"station_ids"`
+	Directions []string   `json:"directions"`
+	Severity   string     `json:"severity"`
+	MessageID  string     `json:"message_id"`
+	MessageEN  string     `json:"message_en"`
+	Message    string     `json:"message,omitempty"`
+	StartsAt   time.Time  `json:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at"`
+	CreatedBy  *int       `json:"created_by"`
+	SourceURL  string     `json:"source_url,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// ServiceAlertInput is the body accepted when an admin creates or updates a
+// service alert. StartsAt defaults to now on creation and to the stored start
+// on update, and a nil EndsAt keeps the alert active until it is ended.
+type ServiceAlertInput struct {
+	StationIDs []int      `json:"station_ids"`
+	Directions []string   `json:"directions"`
+	Severity   string     `json:"severity" binding:"required,oneof=info warning critical"`
+	MessageID  string     `json:"message_id" binding:"required,max=2000"`
+	MessageEN  string     `json:"message_en" binding:"required,max=2000"`
+	StartsAt   *time.Time `json:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at"`
+}
+
+// Events a webhook can subscribe to.
+const (
+	EventTimetableUpdated = "timetable.updated"
+	EventScrapeFailed     = "scrape.failed"
+	EventAlertCreated     = "alert.created"
+)
+
+// Webhook is a partner URL notified of events. Secret signs the payloads and
+// is only returned when the webhook is created.
+type Webhook struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedBy *int      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookInput is the body accepted when an admin registers or updates a
+// webhook. A nil Active keeps the current state, or activates a new webhook.
+type WebhookInput struct {
+	URL    string   `json:"url" binding:"required,url,max=2000"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=timetable.updated scrape.failed alert.created"`
+	Active *bool    `json:"active"`
+}
+
+// Delivery statuses of a webhook event.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event queued for a webhook together with the outcome
+// of its latest attempt.
+type WebhookDelivery struct {
+	ID             int             `json:"id"`
+	WebhookID      int             `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastStatusCode *int            `json:"last_status_code"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+}
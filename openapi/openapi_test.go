@@ -0,0 +1,21 @@
+package openapi_test
+
+import (
+	"testing"
+
+	"web-scrapper/openapi"
+	"web-scrapper/realtime"
+	"web-scrapper/routes"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.Register(router, realtime.NewHub())
+
+	if missing := openapi.Undocumented(router.Routes()); len(missing) > 0 {
+		t.Errorf("routes missing from the OpenAPI spec: %v", missing)
+	}
+}
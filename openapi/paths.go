@@ -0,0 +1,805 @@
+package openapi
+
+import (
+	"strings"
+
+	"web-scrapper/auth"
+	"web-scrapper/cache"
+	"web-scrapper/models"
+	"web-scrapper/response"
+)
+
+var bearerAuth = []map[string][]string{{"bearerAuth": {}}}
+
+// bearerOrAPIKey marks routes machine clients may call with an API key.
+var bearerOrAPIKey = []map[string][]string{{"bearerAuth": {}}, {"apiKeyAuth": {}}}
+
+var (
+	langParam = Parameter{
+		Name:        "lang",
+		In:          "query",
+		Description: "Message language, overrides Accept-Language.",
+		Schema:      Schema{"type": "string", "enum": []string{"id", "en"}},
+	}
+	formatParam = Parameter{
+		Name:        "format",
+		In:          "query",
+		Description: "Response format, overrides the Accept header.",
+		Schema:      Schema{"type": "string", "enum": []string{"json", "ics", "csv"}},
+	}
+	fieldsParam = Parameter{
+		Name:        "fields",
+		In:          "query",
+		Description: "Comma separated fields to return for each item, e.g. id,jadwal.",
+		Schema:      Schema{"type": "string"},
+	}
+	shapeParam = Parameter{
+		Name:        "shape",
+		In:          "query",
+		Description: "flat returns schedule rows, grouped returns times as station ID -> direction -> day type (weekday or weekend) -> [times]. Cannot be combined with fields.",
+		Schema:      Schema{"type": "string", "enum": []string{"flat", "grouped"}, "default": "flat"},
+	}
+	stationIDParam = Parameter{
+		Name:        "id",
+		In:          "path",
+		Description: "Station ID.",
+		Required:    true,
+		Schema:      Schema{"type": "integer"},
+	}
+	alertIDParam = Parameter{
+		Name:        "id",
+		In:          "path",
+		Description: "Service alert ID.",
+		Required:    true,
+		Schema:      Schema{"type": "integer"},
+	}
+	apiKeyIDParam = Parameter{
+		Name:        "id",
+		In:          "path",
+		Description: "API key ID.",
+		Required:    true,
+		Schema:      Schema{"type": "integer"},
+	}
+	userIDParam = Parameter{
+		Name:        "id",
+		In:          "path",
+		Description: "User ID.",
+		Required:    true,
+		Schema:      Schema{"type": "integer"},
+	}
+	webhookIDParam = Parameter{
+		Name:        "id",
+		In:          "path",
+		Description: "Webhook ID.",
+		Required:    true,
+		Schema:      Schema{"type": "integer"},
+	}
+	arahParam = Parameter{
+		Name:        "arah",
+		In:          "path",
+		Description: `Direction, either "Arah Bundaran HI" or "Arah Lebak Bulus".`,
+		Required:    true,
+		Schema:      Schema{"type": "string"},
+	}
+)
+
+var pageParams = []Parameter{
+	{Name: "limit", In: "query", Description: "Page size, at most 500.", Schema: Schema{"type": "integer", "minimum": 1, "maximum": 500, "default": 100}},
+	{Name: "offset", In: "query", Description: "Number of items to skip.", Schema: Schema{"type": "integer", "minimum": 0, "default": 0}},
+}
+
+var departureParams = []Parameter{
+	{Name: "arah", In: "query", Description: "Only departures in this direction.", Schema: Schema{"type": "string"}},
+	{Name: "limit", In: "query", Description: "Departures per direction, at most 20.", Schema: Schema{"type": "integer", "minimum": 1, "maximum": 20, "default": 3}},
+}
+
+// sortParam documents ?sort= with the fields a list can be sorted by.
+func sortParam(fallback string, fields ...string) Parameter {
+	return Parameter{
+		Name:        "sort",
+		In:          "query",
+		Description: "Comma separated fields, prefix with - for descending order. One of: " + strings.Join(fields, ", ") + ".",
+		Schema:      Schema{"type": "string", "default": fallback},
+	}
+}
+
+var scheduleFilterParams = []Parameter{
+	{Name: "station_id", In: "query", Description: "Only schedules of this station.", Schema: Schema{"type": "integer"}},
+	{Name: "arah", In: "query", Description: "Only schedules in this direction.", Schema: Schema{"type": "string"}},
+	{Name: "day", In: "query", Description: "Only the weekday or weekend timetable.", Schema: Schema{"type": "string", "enum": []string{"weekday", "weekend"}}},
+	{Name: "from_time", In: "query", Description: "Earliest departure, HH:MM.", Schema: Schema{"type": "string", "pattern": "^\\d{2}:\\d{2}$"}},
+	{Name: "to_time", In: "query", Description: "Latest departure, HH:MM.", Schema: Schema{"type": "string", "pattern": "^\\d{2}:\\d{2}$"}},
+}
+
+var scheduleListParams = joinParams(pageParams, scheduleFilterParams,
+	[]Parameter{sortParam("id", "id", "station_id", "arah", "jadwal"), fieldsParam, shapeParam, langParam})
+
+var reviewListParams = joinParams(pageParams,
+	[]Parameter{sortParam("-created_at", "id", "rating", "created_at"), fieldsParam, langParam})
+
+func joinParams(groups ...[]Parameter) []Parameter {
+	var params []Parameter
+	for _, group := range groups {
+		params = append(params, group...)
+	}
+	return params
+}
+
+// paginated describes a successful list envelope with pagination metadata.
+func paginated(description string, items Schema) Response {
+	return Response{
+		Description: description,
+		Content: map[string]MediaType{"application/json": {Schema: Schema{
+			"allOf": []Schema{
+				ref("Envelope"),
+				{"type": "object", "properties": Schema{"data": arrayOf(items), "meta": ref("Page")}},
+			},
+		}}},
+	}
+}
+
+// jsonBody describes a JSON request body of the given schema.
+func jsonBody(schema Schema) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// ok describes a successful envelope response carrying data.
+func ok(description string, data Schema) Response {
+	return Response{
+		Description: description,
+		Content:     map[string]MediaType{"application/json": {Schema: envelopeOf(data)}},
+	}
+}
+
+// failure describes a failed envelope response.
+func failure(description string) Response {
+	return Response{
+		Description: description,
+		Content:     map[string]MediaType{"application/json": {Schema: ref("Envelope")}},
+	}
+}
+
+// schedulesExport describes a schedule list that can also be exported.
+func schedulesExport(description string) Response {
+	response := ok(description, arrayOf(ref("Schedule")))
+	response.Content["text/calendar"] = MediaType{Schema: Schema{"type": "string"}}
+	response.Content["text/csv"] = MediaType{Schema: Schema{"type": "string"}}
+	return response
+}
+
+func errorResponses(responses map[string]Response, codes ...string) map[string]Response {
+	for _, code := range codes {
+		switch code {
+		case "304":
+			responses[code] = Response{Description: "Not modified since the ETag or date sent in If-None-Match or If-Modified-Since"}
+		case "400":
+			responses[code] = failure("Invalid request")
+		case "401":
+			responses[code] = failure("Missing, invalid or expired credentials")
+		case "403":
+			responses[code] = failure("The user's role or the API key's scopes do not allow this operation")
+		case "404":
+			responses[code] = failure("Resource not found")
+		case "409":
+			responses[code] = failure("Conflicts with the current state of the resource")
+		case "429":
+			responses[code] = failure("Too many requests; retry after the Retry-After header")
+		case "500":
+			responses[code] = failure("Internal server error")
+		}
+	}
+	return responses
+}
+
+var refreshSchema = Schema{
+	"type": "object",
+	"properties": Schema{
+		"refresh_token": Schema{"type": "string"},
+	},
+	"required": []string{"refresh_token"},
+}
+
+var reviewInputSchema = Schema{
+	"type": "object",
+	"properties": Schema{
+		"rating":  Schema{"type": "number"},
+		"comment": Schema{"type": "string"},
+	},
+}
+
+var document = Document{
+	OpenAPI: "3.0.3",
+	Info: Info{
+		Title:       "MRT-api",
+		Description: "Jakarta MRT timetable scraped daily from jakartamrt.co.id.",
+		Version:     "1.0.0",
+	},
+	Servers: []Server{{URL: "/", Description: "This server"}},
+	Tags: []Tag{
+		{Name: "Authentication"},
+		{Name: "Schedules", Description: "Timetables used by the website"},
+		{Name: "Schedules V1", Description: "Timetables for API consumers"},
+		{Name: "Stations"},
+		{Name: "Reviews"},
+		{Name: "Alerts", Description: "Service alerts and disruption notices"},
+		{Name: "Webhooks", Description: "Event notifications pushed to partner URLs"},
+		{Name: "API Keys", Description: "Credentials of machine clients"},
+		{Name: "Users", Description: "Account management for admins"},
+		{Name: "Documentation"},
+		{Name: "Operations"},
+	},
+	Paths: map[string]PathItem{
+		"/api/v1/register": {
+			"post": {
+				Tags:        []string{"Authentication"},
+				Summary:     "Register a user",
+				Description: "Usernames have 3 to 32 letters, digits, '.', '_' or '-' and start with a letter or digit. Passwords have 8 to 72 bytes and contain letters and digits. The optional email is only used to reset a forgotten password. New users always get the user role.",
+				OperationID: "registerUser",
+				Parameters:  []Parameter{fieldsParam, langParam},
+				RequestBody: jsonBody(ref("RegisterInput")),
+				Responses:   errorResponses(map[string]Response{"200": ok("Registered user", ref("User"))}, "400", "409", "500"),
+			},
+		},
+		"/api/v1/login": {
+			"post": {
+				Tags:        []string{"Authentication"},
+				Summary:     "Log in and receive a JWT",
+				OperationID: "loginUser",
+				Parameters:  []Parameter{langParam},
+				RequestBody: jsonBody(ref("LoginInput")),
+				Responses:   errorResponses(map[string]Response{"200": ok("Access and refresh tokens", ref("TokenPair"))}, "400", "401", "500"),
+			},
+		},
+		"/api/v1/token/refresh": {
+			"post": {
+				Tags:        []string{"Authentication"},
+				Summary:     "Exchange a refresh token for new access and refresh tokens",
+				OperationID: "refreshToken",
+				Parameters:  []Parameter{langParam},
+				RequestBody: jsonBody(refreshSchema),
+				Responses:   errorResponses(map[string]Response{"200": ok("Access and refresh tokens", ref("TokenPair"))}, "400", "401", "500"),
+			},
+		},
+		"/api/v1/logout": {
+			"post": {
+				Tags:        []string{"Authentication"},
+				Summary:     "Revoke the access token and the session of a refresh token, or every session",
+				OperationID: "logout",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{langParam},
+				RequestBody: &RequestBody{Content: map[string]MediaType{"application/json": {Schema: ref("RefreshRequest")}}},
+				Responses:   errorResponses(map[string]Response{"200": ok("Logged out", Schema{"nullable": true})}, "400", "401", "500"),
+			},
+		},
+		"/api/v1/me/password": {
+			"post": {
+				Tags:        []string{"Authentication"},
+				Summary:     "Change the password and end every other session",
+				Description: "The response holds new tokens for this session; tokens issued earlier stop working.",
+				OperationID: "changePassword",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{fieldsParam, langParam},
+				RequestBody: jsonBody(ref("PasswordChangeInput")),
+				Responses:   errorResponses(map[string]Response{"200": ok("Access and refresh tokens", ref("TokenPair"))}, "400", "401", "403", "404", "500"),
+			},
+		},
+		"/api/v1/password/forgot": {
+			"post": {
+				Tags:        []string{"Authentication"},
+				Summary:     "Mail a password reset token",
+				Description: "The answer is the same, and takes the same time, whether or not a user has the email. Tokens expire after an hour and replace the ones sent before. At most 3 requests per email and 10 per client IP are allowed every hour.",
+				OperationID: "forgotPassword",
+				Parameters:  []Parameter{langParam},
+				RequestBody: jsonBody(ref("PasswordForgotInput")),
+				Responses:   errorResponses(map[string]Response{"200": ok("Reset requested", Schema{"nullable": true})}, "400", "429", "500"),
+			},
+		},
+		"/api/v1/password/reset": {
+			"post": {
+				Tags:        []string{"Authentication"},
+				Summary:     "Set a new password with a reset token",
+				Description: "Each token works once. Every session of the user ends.",
+				OperationID: "resetPassword",
+				Parameters:  []Parameter{langParam},
+				RequestBody: jsonBody(ref("PasswordResetInput")),
+				Responses:   errorResponses(map[string]Response{"200": ok("Password reset", Schema{"nullable": true})}, "400", "500"),
+			},
+		},
+		"/.well-known/jwks.json": {
+			"get": {
+				Tags:        []string{"Authentication"},
+				Summary:     "Public keys verifying RS256 and EdDSA tokens",
+				OperationID: "getJWKS",
+				Responses: map[string]Response{"200": {
+					Description: "JSON Web Key Set (RFC 7517), not wrapped in the envelope",
+					Content:     map[string]MediaType{"application/json": {Schema: ref("JWKSet")}},
+				}},
+			},
+		},
+		"/api/v1/reviews": {
+			"get": {
+				Tags:        []string{"Reviews"},
+				Summary:     "List reviews",
+				OperationID: "getAllReviews",
+				Parameters:  reviewListParams,
+				Responses:   errorResponses(map[string]Response{"200": paginated("Page of reviews", ref("Review"))}, "400", "500"),
+			},
+			"post": {
+				Tags:        []string{"Reviews"},
+				Summary:     "Create a review",
+				OperationID: "createReview",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{fieldsParam, langParam},
+				RequestBody: jsonBody(reviewInputSchema),
+				Responses:   errorResponses(map[string]Response{"201": ok("Created review", ref("Review"))}, "400", "401", "404", "500"),
+			},
+		},
+		"/api/v1/alerts": {
+			"get": {
+				Tags:        []string{"Alerts"},
+				Summary:     "List the service alerts in effect",
+				OperationID: "getActiveAlerts",
+				Parameters: []Parameter{
+					{Name: "station_id", In: "query", Description: "Only alerts affecting this station.", Schema: Schema{"type": "integer"}},
+					{Name: "arah", In: "query", Description: "With station_id, only alerts affecting this direction.", Schema: Schema{"type": "string"}},
+					fieldsParam, langParam,
+				},
+				Responses: errorResponses(map[string]Response{"200": ok("Active alerts, most severe first", arrayOf(ref("ServiceAlert")))}, "400"),
+			},
+		},
+		"/api/v1/admin/alerts": {
+			"get": {
+				Tags:        []string{"Alerts"},
+				Summary:     "List every service alert, ended ones included",
+				Description: "Requires the alerts:manage permission.",
+				OperationID: "listAlerts",
+				Security:    bearerAuth,
+				Parameters:  joinParams(pageParams, []Parameter{fieldsParam, langParam}),
+				Responses:   errorResponses(map[string]Response{"200": paginated("Page of alerts", ref("ServiceAlert"))}, "400", "401", "403", "500"),
+			},
+			"post": {
+				Tags:        []string{"Alerts"},
+				Summary:     "Create a service alert",
+				Description: "Requires the alerts:manage permission.",
+				OperationID: "createAlert",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{fieldsParam, langParam},
+				RequestBody: jsonBody(ref("ServiceAlertInput")),
+				Responses:   errorResponses(map[string]Response{"201": ok("Created alert", ref("ServiceAlert"))}, "400", "401", "403", "500"),
+			},
+		},
+		"/api/v1/admin/alerts/{id}": {
+			"get": {
+				Tags:        []string{"Alerts"},
+				Summary:     "Get a service alert",
+				Description: "Requires the alerts:manage permission.",
+				OperationID: "getAlert",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{alertIDParam, fieldsParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": ok("Alert", ref("ServiceAlert"))}, "400", "401", "403", "404", "500"),
+			},
+			"put": {
+				Tags:        []string{"Alerts"},
+				Summary:     "Replace a service alert",
+				Description: "Requires the alerts:manage permission.",
+				OperationID: "updateAlert",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{alertIDParam, fieldsParam, langParam},
+				RequestBody: jsonBody(ref("ServiceAlertInput")),
+				Responses:   errorResponses(map[string]Response{"200": ok("Updated alert", ref("ServiceAlert"))}, "400", "401", "403", "404", "500"),
+			},
+			"delete": {
+				Tags:        []string{"Alerts"},
+				Summary:     "Delete a service alert",
+				Description: "Requires the alerts:manage permission.",
+				OperationID: "deleteAlert",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{alertIDParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": ok("Alert deleted", Schema{"nullable": true})}, "400", "401", "403", "404", "500"),
+			},
+		},
+		"/api/secure_endpoint": {
+			"get": {
+				Tags:        []string{"Authentication"},
+				Summary:     "Check that a token is accepted",
+				OperationID: "secureEndpoint",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{langParam},
+				Responses:   errorResponses(map[string]Response{"200": ok("Token accepted", Schema{"nullable": true})}, "401"),
+			},
+		},
+		"/api/stasiun": {
+			"get": {
+				Tags:        []string{"Stations"},
+				Summary:     "List stations",
+				OperationID: "getAllStasiun",
+				Security:    bearerOrAPIKey,
+				Parameters:  []Parameter{fieldsParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": ok("Stations", arrayOf(ref("Stasiun")))}, "304", "401", "403", "500"),
+			},
+		},
+		"/api/schedules": {
+			"get": {
+				Tags:        []string{"Schedules"},
+				Summary:     "List all schedules",
+				OperationID: "getAllSchedules",
+				Security:    bearerOrAPIKey,
+				Parameters:  scheduleListParams,
+				Responses:   errorResponses(map[string]Response{"200": paginated("Page of schedules", ref("Schedule"))}, "304", "400", "401", "403", "500"),
+			},
+		},
+		"/api/schedules/{id}": {
+			"get": {
+				Tags:        []string{"Schedules"},
+				Summary:     "List schedules of a station",
+				OperationID: "getSchedulesByID",
+				Security:    bearerOrAPIKey,
+				Parameters:  []Parameter{stationIDParam, formatParam, fieldsParam, shapeParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": schedulesExport("Schedules of the station")}, "304", "400", "401", "403", "404", "500"),
+			},
+		},
+		"/api/schedules/{id}/{arah}": {
+			"get": {
+				Tags:        []string{"Schedules"},
+				Summary:     "List today's schedules of a station in one direction",
+				Description: "Returns the weekend timetable on Saturday and Sunday and the weekday timetable otherwise.",
+				OperationID: "getSchedulesByIDAndTrip",
+				Security:    bearerOrAPIKey,
+				Parameters:  []Parameter{stationIDParam, arahParam, formatParam, fieldsParam, shapeParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": schedulesExport("Schedules of the station in the direction")}, "304", "400", "401", "403", "500"),
+			},
+		},
+		"/api/v1/stasiun": {
+			"get": {
+				Tags:        []string{"Stations"},
+				Summary:     "List stations",
+				OperationID: "getAllStasiunV1",
+				Security:    bearerOrAPIKey,
+				Parameters:  []Parameter{fieldsParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": ok("Stations", arrayOf(ref("Stasiun")))}, "304", "401", "403", "500"),
+			},
+		},
+		"/api/v1/schedules": {
+			"get": {
+				Tags:        []string{"Schedules V1"},
+				Summary:     "List all schedules",
+				OperationID: "getAllSchedulesV1",
+				Security:    bearerOrAPIKey,
+				Parameters:  scheduleListParams,
+				Responses:   errorResponses(map[string]Response{"200": paginated("Page of schedules", ref("Schedule"))}, "304", "400", "401", "403", "500"),
+			},
+		},
+		"/api/v1/schedules/{id}": {
+			"get": {
+				Tags:        []string{"Schedules V1"},
+				Summary:     "List schedules of a station",
+				OperationID: "getSchedulesByStationIDV1",
+				Security:    bearerOrAPIKey,
+				Parameters:  []Parameter{stationIDParam, formatParam, fieldsParam, shapeParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": schedulesExport("Schedules of the station")}, "304", "400", "401", "403", "404", "500"),
+			},
+		},
+		"/api/v1/schedules/{id}/{arah}": {
+			"get": {
+				Tags:        []string{"Schedules V1"},
+				Summary:     "List weekday schedules of a station in one direction",
+				OperationID: "getSchedulesByIDAndTripV1",
+				Security:    bearerOrAPIKey,
+				Parameters:  []Parameter{stationIDParam, arahParam, formatParam, fieldsParam, shapeParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": schedulesExport("Schedules of the station in the direction")}, "304", "400", "401", "403", "404", "500"),
+			},
+		},
+		"/api/v1/stations/{id}/departures": {
+			"get": {
+				Tags:        []string{"Stations"},
+				Summary:     "Next departures from a station",
+				OperationID: "getNextDepartures",
+				Security:    bearerOrAPIKey,
+				Parameters:  joinParams([]Parameter{stationIDParam}, departureParams, []Parameter{fieldsParam, langParam}),
+				Responses:   errorResponses(map[string]Response{"200": ok("Upcoming departures in departure order", arrayOf(ref("Departure")))}, "400", "401", "403", "404"),
+			},
+		},
+		"/api/v1/stations/{id}/departures/stream": {
+			"get": {
+				Tags:        []string{"Stations"},
+				Summary:     "Server-Sent Events stream of the next departures from a station",
+				Description: "Sends a departures event on connect, whenever the earliest departure has left and whenever the timetable is refreshed. Idle streams receive a keep-alive comment every 30 seconds.",
+				OperationID: "streamDepartures",
+				Security:    bearerOrAPIKey,
+				Parameters:  joinParams([]Parameter{stationIDParam}, departureParams),
+				Responses: errorResponses(map[string]Response{"200": {
+					Description: `Event stream of "departures" events whose data has a reason (initial, departed or timetable_updated), the timetable version and the departures`,
+					Content:     map[string]MediaType{"text/event-stream": {Schema: Schema{"type": "string"}}},
+				}}, "400", "401", "403", "404"),
+			},
+		},
+		"/api/v1/ws": {
+			"get": {
+				Tags:        []string{"Stations"},
+				Summary:     "WebSocket hub for departures, timetable changes and alerts",
+				Description: `Send {"action":"subscribe","station_id":20,"arah":"Arah Bundaran HI"} (omit arah for both directions) or "unsubscribe". Subscribers receive departures messages every 30 seconds, timetable_changed when the timetable is refreshed and alert messages for their stations. Browsers, which cannot send headers here, pass a ticket from /api/v1/ws/ticket instead; pages must be served from the API's origin or one listed in WS_ALLOWED_ORIGINS.`,
+				OperationID: "serveWebSocket",
+				// A ticket in the query replaces the credentials in the headers.
+				Security: append(bearerOrAPIKey, map[string][]string{}),
+				Parameters: []Parameter{
+					{Name: "ticket", In: "query", Description: "Single-use ticket from /api/v1/ws/ticket, valid for 30 seconds.", Schema: Schema{"type": "string"}},
+					langParam,
+				},
+				Responses: errorResponses(map[string]Response{
+					"101": {Description: "Switched to the WebSocket protocol"},
+					"400": {Description: "Not a WebSocket handshake"},
+				}, "401", "403"),
+			},
+		},
+		"/api/v1/ws/ticket": {
+			"post": {
+				Tags:        []string{"Stations"},
+				Summary:     "Exchange the access token for a WebSocket ticket",
+				Description: "Browsers cannot set headers on WebSocket requests, so they connect to /api/v1/ws?ticket=... with this ticket instead. It is valid for 30 seconds and works once.",
+				OperationID: "issueWebSocketTicket",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{fieldsParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": ok("WebSocket ticket", ref("WebSocketTicket"))}, "401", "403", "500"),
+			},
+		},
+		"/api/v1/stations/{id}/timetable.html": {
+			"get": {
+				Tags:        []string{"Stations"},
+				Summary:     "Printable HTML timetable of a station",
+				OperationID: "getStationTimetableHTML",
+				Security:    bearerOrAPIKey,
+				Parameters:  []Parameter{stationIDParam},
+				Responses: errorResponses(map[string]Response{"200": {
+					Description: "Timetable poster",
+					Content:     map[string]MediaType{"text/html": {Schema: Schema{"type": "string"}}},
+				}}, "304", "400", "401", "403", "404", "500"),
+			},
+		},
+		"/api/v1/stations/{id}/timetable.pdf": {
+			"get": {
+				Tags:        []string{"Stations"},
+				Summary:     "Printable PDF timetable of a station",
+				OperationID: "getStationTimetablePDF",
+				Security:    bearerOrAPIKey,
+				Parameters:  []Parameter{stationIDParam},
+				Responses: errorResponses(map[string]Response{"200": {
+					Description: "Timetable poster",
+					Content:     map[string]MediaType{"application/pdf": {Schema: Schema{"type": "string", "format": "binary"}}},
+				}}, "304", "400", "401", "403", "404", "500"),
+			},
+		},
+		"/api/v1/admin/webhooks": {
+			"get": {
+				Tags:        []string{"Webhooks"},
+				Summary:     "List webhooks",
+				Description: "Requires the webhooks:manage permission.",
+				OperationID: "listWebhooks",
+				Security:    bearerAuth,
+				Parameters:  joinParams(pageParams, []Parameter{fieldsParam, langParam}),
+				Responses:   errorResponses(map[string]Response{"200": paginated("Page of webhooks", ref("Webhook"))}, "400", "401", "403", "500"),
+			},
+			"post": {
+				Tags:        []string{"Webhooks"},
+				Summary:     "Register a webhook; the response holds its signing secret",
+				Description: "Requires the webhooks:manage permission.",
+				OperationID: "createWebhook",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{fieldsParam, langParam},
+				RequestBody: jsonBody(ref("WebhookInput")),
+				Responses:   errorResponses(map[string]Response{"201": ok("Registered webhook", ref("Webhook"))}, "400", "401", "403", "500"),
+			},
+		},
+		"/api/v1/admin/webhooks/{id}": {
+			"get": {
+				Tags:        []string{"Webhooks"},
+				Summary:     "Get a webhook",
+				Description: "Requires the webhooks:manage permission.",
+				OperationID: "getWebhook",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{webhookIDParam, fieldsParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": ok("Webhook", ref("Webhook"))}, "400", "401", "403", "404", "500"),
+			},
+			"put": {
+				Tags:        []string{"Webhooks"},
+				Summary:     "Update a webhook",
+				Description: "Requires the webhooks:manage permission.",
+				OperationID: "updateWebhook",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{webhookIDParam, fieldsParam, langParam},
+				RequestBody: jsonBody(ref("WebhookInput")),
+				Responses:   errorResponses(map[string]Response{"200": ok("Updated webhook", ref("Webhook"))}, "400", "401", "403", "404", "500"),
+			},
+			"delete": {
+				Tags:        []string{"Webhooks"},
+				Summary:     "Delete a webhook and its delivery log",
+				Description: "Requires the webhooks:manage permission.",
+				OperationID: "deleteWebhook",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{webhookIDParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": ok("Webhook deleted", Schema{"nullable": true})}, "400", "401", "403", "404", "500"),
+			},
+		},
+		"/api/v1/admin/webhooks/{id}/deliveries": {
+			"get": {
+				Tags:        []string{"Webhooks"},
+				Summary:     "Delivery log of a webhook, newest first",
+				Description: "Requires the webhooks:manage permission.",
+				OperationID: "listWebhookDeliveries",
+				Security:    bearerAuth,
+				Parameters: joinParams(pageParams, []Parameter{
+					webhookIDParam,
+					{Name: "status", In: "query", Description: "Only deliveries in this state.", Schema: Schema{"type": "string", "enum": []string{"pending", "delivered", "failed"}}},
+					fieldsParam, langParam,
+				}),
+				Responses: errorResponses(map[string]Response{"200": paginated("Page of deliveries", ref("WebhookDelivery"))}, "400", "401", "403", "404", "500"),
+			},
+		},
+		"/api/v1/admin/api-keys": {
+			"get": {
+				Tags:        []string{"API Keys"},
+				Summary:     "List API keys, revoked ones included",
+				Description: "Requires the api_keys:manage permission.",
+				OperationID: "listAPIKeys",
+				Security:    bearerAuth,
+				Parameters:  joinParams(pageParams, []Parameter{fieldsParam, langParam}),
+				Responses:   errorResponses(map[string]Response{"200": paginated("Page of API keys", ref("APIKey"))}, "400", "401", "403", "500"),
+			},
+			"post": {
+				Tags:        []string{"API Keys"},
+				Summary:     "Create an API key; the response holds the key",
+				Description: "Requires the api_keys:manage permission.",
+				OperationID: "createAPIKey",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{fieldsParam, langParam},
+				RequestBody: jsonBody(ref("APIKeyInput")),
+				Responses:   errorResponses(map[string]Response{"201": ok("Created API key", ref("APIKey"))}, "400", "401", "403", "500"),
+			},
+		},
+		"/api/v1/admin/api-keys/{id}": {
+			"delete": {
+				Tags:        []string{"API Keys"},
+				Summary:     "Revoke an API key",
+				Description: "Requires the api_keys:manage permission.",
+				OperationID: "revokeAPIKey",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{apiKeyIDParam, fieldsParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": ok("Revoked API key", ref("APIKey"))}, "400", "401", "403", "404", "500"),
+			},
+		},
+		"/api/v1/admin/users": {
+			"get": {
+				Tags:        []string{"Users"},
+				Summary:     "List and search users",
+				Description: "Requires the users:manage permission.",
+				OperationID: "listUsers",
+				Security:    bearerAuth,
+				Parameters: joinParams(pageParams, []Parameter{
+					{Name: "q", In: "query", Description: "Part of the username or email, ignoring case.", Schema: Schema{"type": "string"}},
+					{Name: "role", In: "query", Description: "Only users with this role.", Schema: Schema{"type": "string", "enum": []string{"user", "admin"}}},
+					{Name: "disabled", In: "query", Description: "Only disabled or only enabled accounts.", Schema: Schema{"type": "boolean"}},
+					fieldsParam, langParam,
+				}),
+				Responses: errorResponses(map[string]Response{"200": paginated("Page of users", ref("UserAccount"))}, "400", "401", "403", "500"),
+			},
+		},
+		"/api/v1/admin/users/{id}": {
+			"get": {
+				Tags:        []string{"Users"},
+				Summary:     "Get a user",
+				Description: "Requires the users:manage permission.",
+				OperationID: "getUser",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{userIDParam, fieldsParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": ok("User", ref("UserAccount"))}, "400", "401", "403", "404", "500"),
+			},
+			"delete": {
+				Tags:        []string{"Users"},
+				Summary:     "Delete a user",
+				Description: "Requires the users:manage permission. Admins cannot delete their own account.",
+				OperationID: "deleteUser",
+				Security:    bearerAuth,
+				Parameters: []Parameter{
+					userIDParam,
+					{Name: "reviews", In: "query", Description: "anonymize keeps the user's reviews without an author, delete removes them.", Schema: Schema{"type": "string", "enum": []string{"anonymize", "delete"}, "default": "anonymize"}},
+					langParam,
+				},
+				Responses: errorResponses(map[string]Response{"200": ok("User deleted", Schema{"nullable": true})}, "400", "401", "403", "404", "409", "500"),
+			},
+		},
+		"/api/v1/admin/users/{id}/role": {
+			"put": {
+				Tags:        []string{"Users"},
+				Summary:     "Promote or demote a user",
+				Description: "Requires the users:manage permission. Admins cannot change their own role.",
+				OperationID: "updateUserRole",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{userIDParam, fieldsParam, langParam},
+				RequestBody: jsonBody(ref("RoleInput")),
+				Responses:   errorResponses(map[string]Response{"200": ok("Updated user", ref("UserAccount"))}, "400", "401", "403", "404", "409", "500"),
+			},
+		},
+		"/api/v1/admin/users/{id}/disable": {
+			"post": {
+				Tags:        []string{"Users"},
+				Summary:     "Disable an account and end its sessions",
+				Description: "Requires the users:manage permission. Admins cannot disable their own account.",
+				OperationID: "disableUser",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{userIDParam, fieldsParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": ok("Disabled user", ref("UserAccount"))}, "400", "401", "403", "404", "409", "500"),
+			},
+		},
+		"/api/v1/admin/users/{id}/enable": {
+			"post": {
+				Tags:        []string{"Users"},
+				Summary:     "Enable a disabled account",
+				Description: "Requires the users:manage permission.",
+				OperationID: "enableUser",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{userIDParam, fieldsParam, langParam},
+				Responses:   errorResponses(map[string]Response{"200": ok("Enabled user", ref("UserAccount"))}, "400", "401", "403", "404", "500"),
+			},
+		},
+		"/api/v1/admin/cache/stats": {
+			"get": {
+				Tags:        []string{"Operations"},
+				Summary:     "Cache hit and miss counts per namespace",
+				Description: "Requires the operations:read permission.",
+				OperationID: "getCacheStats",
+				Security:    bearerAuth,
+				Parameters:  []Parameter{langParam},
+				Responses:   errorResponses(map[string]Response{"200": ok("Cache statistics", arrayOf(ref("CacheStats")))}, "401", "403"),
+			},
+		},
+		"/api/openapi.json": {
+			"get": {
+				Tags:        []string{"Documentation"},
+				Summary:     "This OpenAPI document",
+				OperationID: "getOpenAPI",
+				Responses: map[string]Response{"200": {
+					Description: "OpenAPI 3 document",
+					Content:     map[string]MediaType{"application/json": {Schema: Schema{"type": "object"}}},
+				}},
+			},
+		},
+	},
+	Components: Components{
+		Schemas: map[string]Schema{
+			"Schedule":            schemaOf(models.Schedule{}),
+			"Stasiun":             schemaOf(models.Stasiun{}),
+			"Review":              schemaOf(models.Review{}),
+			"Departure":           schemaOf(models.Departure{}),
+			"ServiceAlert":        schemaOf(models.ServiceAlert{}),
+			"ServiceAlertInput":   schemaOf(models.ServiceAlertInput{}),
+			"Webhook":             schemaOf(models.Webhook{}),
+			"WebhookInput":        schemaOf(models.WebhookInput{}),
+			"WebhookDelivery":     schemaOf(models.WebhookDelivery{}),
+			"User":                schemaOf(models.User{}),
+			"RegisterInput":       schemaOf(models.RegisterInput{}),
+			"LoginInput":          schemaOf(models.LoginInput{}),
+			"PasswordChangeInput": schemaOf(models.PasswordChangeInput{}),
+			"PasswordForgotInput": schemaOf(models.PasswordForgotInput{}),
+			"PasswordResetInput":  schemaOf(models.PasswordResetInput{}),
+			"WebSocketTicket":     schemaOf(models.WebSocketTicket{}),
+			"TokenPair":           schemaOf(models.TokenPair{}),
+			"APIKey":              schemaOf(models.APIKey{}),
+			"UserAccount":         schemaOf(models.UserAccount{}),
+			"RoleInput":           schemaOf(models.RoleInput{}),
+			"APIKeyInput":         schemaOf(models.APIKeyInput{}),
+			"RefreshRequest":      schemaOf(models.RefreshRequest{}),
+			"Envelope":            schemaOf(response.Envelope{}),
+			"ErrorBody":           schemaOf(response.ErrorBody{}),
+			"FieldError":          schemaOf(response.FieldError{}),
+			"Page":                schemaOf(response.Page{}),
+			"CacheStats":          schemaOf(cache.Stats{}),
+			"JWKSet":              schemaOf(auth.JWKSet{}),
+		},
+		SecuritySchemes: map[string]SecurityScheme{
+			"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			"apiKeyAuth": {Type: "apiKey", In: "header", Name: "X-API-Key"},
+		},
+	},
+}
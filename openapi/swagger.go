@@ -0,0 +1,46 @@
+package openapi
+
+import (
+	"io/fs"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// swaggerInitializer replaces the initializer shipped with swagger-ui-dist so
+// the bundled UI loads this API's spec instead of the petstore example.
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "/api/openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+var swaggerFileServer = http.StripPrefix("/api/docs", http.FileServer(http.FS(swaggerFiles.FS)))
+
+// ServeSwaggerUI serves the bundled Swagger UI under /api/docs.
+func ServeSwaggerUI(c *gin.Context) {
+	switch c.Param("filepath") {
+	case "", "/", "/index.html":
+		// http.FileServer redirects index.html to the directory, so the page
+		// is written directly instead.
+		index, err := fs.ReadFile(swaggerFiles.FS, "index.html")
+		if err != nil {
+			log.Printf("Error reading Swagger UI index: %v", err)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	case "/swagger-initializer.js":
+		c.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(swaggerInitializer))
+	default:
+		swaggerFileServer.ServeHTTP(c.Writer, c.Request)
+	}
+}
@@ -0,0 +1,131 @@
+package realtime
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"web-scrapper/response"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+	maxRequestSize = 512
+	// queueSize is how many messages may wait for a slow client before it
+	// is disconnected.
+	queueSize = 64
+)
+
+// client is one WebSocket connection. Its topics and queue are guarded by
+// the hub's mutex.
+type client struct {
+	hub    *Hub
+	conn   *websocket.Conn
+	lang   string
+	queue  chan []byte
+	topics map[topic]struct{}
+}
+
+func newClient(hub *Hub, conn *websocket.Conn, lang string) *client {
+	return &client{
+		hub:    hub,
+		conn:   conn,
+		lang:   lang,
+		queue:  make(chan []byte, queueSize),
+		topics: make(map[topic]struct{}),
+	}
+}
+
+// enqueue queues an encoded message without blocking the hub. A client whose
+// queue is full is too slow to keep up and gets disconnected. The caller
+// holds the hub's mutex.
+func (cl *client) enqueue(message []byte) {
+	select {
+	case cl.queue <- message:
+	default:
+		log.Println("Disconnecting slow WebSocket client")
+		cl.conn.Close()
+	}
+}
+
+// send encodes and queues a message for this client only.
+func (cl *client) send(m Message) {
+	message, err := encode(m)
+	if err != nil {
+		return
+	}
+	cl.hub.mu.Lock()
+	defer cl.hub.mu.Unlock()
+	if _, connected := cl.hub.clients[cl]; connected {
+		cl.enqueue(message)
+	}
+}
+
+func (cl *client) sendError(code string, args ...interface{}) {
+	cl.send(errorMessage(cl.lang, code, args...))
+}
+
+// readPump applies the subscription requests of the client until the
+// connection closes, then removes the client from the hub.
+func (cl *client) readPump() {
+	defer func() {
+		cl.hub.remove(cl)
+		cl.conn.Close()
+	}()
+
+	cl.conn.SetReadLimit(maxRequestSize)
+	cl.conn.SetReadDeadline(time.Now().Add(pongWait))
+	cl.conn.SetPongHandler(func(string) error {
+		return cl.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := cl.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("Error reading WebSocket message: %v", err)
+			}
+			return
+		}
+
+		var request Request
+		if err := json.Unmarshal(data, &request); err != nil {
+			cl.sendError(response.CodeInvalidRequest)
+			continue
+		}
+		cl.hub.handle(cl, request)
+	}
+}
+
+// writePump writes queued messages and keeps the connection alive with
+// pings until the queue is closed by the hub.
+func (cl *client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		cl.conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-cl.queue:
+			cl.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				cl.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := cl.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			cl.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := cl.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package realtime
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"web-scrapper/i18n"
+	"web-scrapper/response"
+	"web-scrapper/timetable"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// tickInterval is how often subscribers get fresh departure countdowns.
+	tickInterval = 30 * time.Second
+	// departureLimit is the number of departures sent per direction.
+	departureLimit = 3
+	// maxSubscriptions bounds the topics of one connection.
+	maxSubscriptions = 64
+)
+
+// topic is a station and direction a client can subscribe to. An empty Arah
+// covers every direction of the station.
+type topic struct {
+	StationID int
+	Arah      string
+}
+
+// Hub keeps the WebSocket clients and their subscriptions and fans departure
+// countdowns, timetable changes and alerts out to them.
+type Hub struct {
+	mu       sync.Mutex
+	clients  map[*client]struct{}
+	topics   map[topic]map[*client]struct{}
+	origins  map[string]bool
+	upgrader websocket.Upgrader
+}
+
+// NewHub returns an empty hub. Browser pages may connect from the API's own
+// origin and from allowedOrigins, such as https://dashboard.example; "*"
+// allows every origin. Run must be started for subscribers to get ticks.
+func NewHub(allowedOrigins ...string) *Hub {
+	h := &Hub{
+		clients: make(map[*client]struct{}),
+		topics:  make(map[topic]map[*client]struct{}),
+		origins: make(map[string]bool),
+	}
+	for _, origin := range allowedOrigins {
+		if origin = normalizeOrigin(origin); origin != "" {
+			h.origins[origin] = true
+		}
+	}
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     h.checkOrigin,
+	}
+	return h
+}
+
+// checkOrigin lets clients without an Origin header through, since only
+// browsers send one, and browser pages from the API's own origin or an
+// allowed one.
+func (h *Hub) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || h.origins["*"] {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return h.origins[normalizeOrigin(origin)]
+}
+
+func normalizeOrigin(origin string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/")
+}
+
+// Run sends departure countdowns to every subscriber each tickInterval and
+// announces every timetable swapped in by timetable.Refresh. It blocks for
+// the lifetime of the process.
+func (h *Hub) Run() {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	updates, unsubscribe := timetable.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.tick()
+		case version := <-updates:
+			h.broadcast(Message{Type: TypeTimetableChanged, Version: version.ETag})
+			h.tick()
+		}
+	}
+}
+
+// ServeWS upgrades the request to a WebSocket connection served by the hub.
+func (h *Hub) ServeWS(c *gin.Context) {
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written the error response.
+		log.Printf("Error upgrading WebSocket connection: %v", err)
+		return
+	}
+
+	cl := newClient(h, conn, response.Lang(c))
+	h.mu.Lock()
+	h.clients[cl] = struct{}{}
+	h.mu.Unlock()
+
+	go cl.writePump()
+	cl.readPump()
+}
+
+// PublishAlert sends an alert to the clients subscribed to any of the given
+// stations, or to every client when no station is given.
+func (h *Hub) PublishAlert(alert interface{}, stationIDs ...int) {
+	message, err := encode(Message{Type: TypeAlert, Alert: alert})
+	if err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(stationIDs) == 0 {
+		for cl := range h.clients {
+			cl.enqueue(message)
+		}
+		return
+	}
+
+	affected := make(map[int]bool, len(stationIDs))
+	for _, id := range stationIDs {
+		affected[id] = true
+	}
+	sent := make(map[*client]bool)
+	for t, subscribers := range h.topics {
+		if !affected[t.StationID] {
+			continue
+		}
+		for cl := range subscribers {
+			if !sent[cl] {
+				sent[cl] = true
+				cl.enqueue(message)
+			}
+		}
+	}
+}
+
+// handle applies a subscription request of a client.
+func (h *Hub) handle(cl *client, request Request) {
+	t := topic{StationID: request.StationID, Arah: request.Arah}
+	switch request.Action {
+	case ActionSubscribe:
+		if code, args := validate(t); code != "" {
+			cl.sendError(code, args...)
+			return
+		}
+		h.mu.Lock()
+		if _, subscribed := cl.topics[t]; !subscribed && len(cl.topics) >= maxSubscriptions {
+			h.mu.Unlock()
+			cl.sendError(response.CodeTooManySubscriptions, maxSubscriptions)
+			return
+		}
+		cl.topics[t] = struct{}{}
+		if h.topics[t] == nil {
+			h.topics[t] = make(map[*client]struct{})
+		}
+		h.topics[t][cl] = struct{}{}
+		h.mu.Unlock()
+
+		cl.send(Message{Type: TypeSubscribed, StationID: t.StationID, Arah: t.Arah})
+		cl.send(departures(timetable.CurrentIndex(), t, time.Now()))
+	case ActionUnsubscribe:
+		h.mu.Lock()
+		h.unsubscribe(cl, t)
+		h.mu.Unlock()
+		cl.send(Message{Type: TypeUnsubscribed, StationID: t.StationID, Arah: t.Arah})
+	default:
+		cl.sendError(response.CodeInvalidParameter, "action")
+	}
+}
+
+// unsubscribe removes a client from a topic. The caller holds h.mu.
+func (h *Hub) unsubscribe(cl *client, t topic) {
+	delete(cl.topics, t)
+	delete(h.topics[t], cl)
+	if len(h.topics[t]) == 0 {
+		delete(h.topics, t)
+	}
+}
+
+// remove forgets a disconnected client and closes its send queue.
+func (h *Hub) remove(cl *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[cl]; !ok {
+		return
+	}
+	for t := range cl.topics {
+		h.unsubscribe(cl, t)
+	}
+	delete(h.clients, cl)
+	close(cl.queue)
+}
+
+// tick sends the current departures of every subscribed topic, computing
+// each topic once however many clients follow it.
+func (h *Hub) tick() {
+	index := timetable.CurrentIndex()
+	now := time.Now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for t, subscribers := range h.topics {
+		message, err := encode(departures(index, t, now))
+		if err != nil {
+			continue
+		}
+		for cl := range subscribers {
+			cl.enqueue(message)
+		}
+	}
+}
+
+// broadcast sends a message to every connected client.
+func (h *Hub) broadcast(m Message) {
+	message, err := encode(m)
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for cl := range h.clients {
+		cl.enqueue(message)
+	}
+}
+
+// validate checks a topic against the timetable index and returns the error
+// code and arguments describing why it cannot be subscribed to.
+func validate(t topic) (string, []interface{}) {
+	index := timetable.CurrentIndex()
+	if _, found := index.Station(t.StationID); !found {
+		return response.CodeStationNotFound, []interface{}{strconv.Itoa(t.StationID)}
+	}
+	if t.Arah == "" {
+		return "", nil
+	}
+	for _, arah := range index.Directions(t.StationID) {
+		if arah == t.Arah {
+			return "", nil
+		}
+	}
+	return response.CodeScheduleNotFound, []interface{}{strconv.Itoa(t.StationID), t.Arah}
+}
+
+// departures builds the departures message of a topic.
+func departures(index *timetable.Index, t topic, now time.Time) Message {
+	directions := []string{t.Arah}
+	if t.Arah == "" {
+		directions = index.Directions(t.StationID)
+	}
+	return Message{
+		Type:       TypeDepartures,
+		StationID:  t.StationID,
+		Arah:       t.Arah,
+		Version:    index.Version().ETag,
+		Departures: index.UpcomingDepartures(t.StationID, directions, now, departureLimit),
+	}
+}
+
+func encode(m Message) ([]byte, error) {
+	message, err := json.Marshal(m)
+	if err != nil {
+		log.Printf("Error encoding %s message: %v", m.Type, err)
+	}
+	return message, err
+}
+
+// errorMessage builds a localized error message.
+func errorMessage(lang string, code string, args ...interface{}) Message {
+	return Message{Type: TypeError, Code: code, Error: i18n.T(lang, code, args...)}
+}
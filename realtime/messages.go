@@ -0,0 +1,39 @@
+package realtime
+
+import "web-scrapper/models"
+
+// Types of the messages exchanged over the WebSocket connection.
+const (
+	// Sent by clients.
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+
+	// Sent by the hub.
+	TypeSubscribed       = "subscribed"
+	TypeUnsubscribed     = "unsubscribed"
+	TypeDepartures       = "departures"
+	TypeTimetableChanged = "timetable_changed"
+	TypeAlert            = "alert"
+	TypeError            = "error"
+)
+
+// Request is a message sent by a client to change its subscriptions. An empty
+// Arah stands for every direction of the station.
+type Request struct {
+	Action    string `json:"action"`
+	StationID int    `json:"station_id"`
+	Arah      string `json:"arah,omitempty"`
+}
+
+// Message is sent by the hub to its clients. Only the fields relevant to Type
+// are set.
+type Message struct {
+	Type       string             `json:"type"`
+	StationID  int                `json:"station_id,omitempty"`
+	Arah       string             `json:"arah,omitempty"`
+	Version    string             `json:"version,omitempty"`
+	Departures []models.Departure `json:"departures,omitempty"`
+	Alert      interface{}        `json:"alert,omitempty"`
+	Code       string             `json:"code,omitempty"`
+	Error      string             `json:"error,omitempty"`
+}
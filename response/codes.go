@@ -0,0 +1,80 @@
+package response
+
+// Error codes returned in the error.code field of a failed envelope. Each code
+// is also the key of its message in the i18n catalog.
+const (
+	CodeInternal          = "INTERNAL_ERROR"
+	CodeInvalidRequest    = "INVALID_REQUEST"
+	CodeValidationFailed  = "VALIDATION_FAILED"
+	CodeInvalidParameter  = "INVALID_PARAMETER"
+	CodeRouteNotFound     = "ROUTE_NOT_FOUND"
+	CodeUnsupportedFormat = "UNSUPPORTED_FORMAT"
+
+	CodeMissingToken        = "MISSING_TOKEN"
+	CodeInvalidToken        = "INVALID_TOKEN"
+	CodeTokenRevoked        = "TOKEN_REVOKED"
+	CodeInvalidRefreshToken = "INVALID_REFRESH_TOKEN"
+	CodeInvalidAPIKey       = "INVALID_API_KEY"
+	CodeInsufficientScope   = "INSUFFICIENT_SCOPE"
+	CodeInvalidCredentials  = "INVALID_CREDENTIALS"
+	CodeUnauthorized        = "UNAUTHORIZED"
+	CodeForbidden           = "FORBIDDEN"
+	CodeUserNotFound        = "USER_NOT_FOUND"
+	CodeAccountDisabled     = "ACCOUNT_DISABLED"
+	CodeCannotModifySelf    = "CANNOT_MODIFY_SELF"
+	CodeUsernameTaken       = "USERNAME_TAKEN"
+	CodeEmailTaken          = "EMAIL_TAKEN"
+	CodeIncorrectPassword   = "INCORRECT_PASSWORD"
+	CodeInvalidResetToken   = "INVALID_RESET_TOKEN"
+	CodeRegistrationFailed  = "REGISTRATION_FAILED"
+
+	CodeInvalidStationID     = "INVALID_STATION_ID"
+	CodeStationNotFound      = "STATION_NOT_FOUND"
+	CodeScheduleNotFound     = "SCHEDULE_NOT_FOUND"
+	CodeAlertNotFound        = "ALERT_NOT_FOUND"
+	CodeWebhookNotFound      = "WEBHOOK_NOT_FOUND"
+	CodeAPIKeyNotFound       = "API_KEY_NOT_FOUND"
+	CodeTooManySubscriptions = "TOO_MANY_SUBSCRIPTIONS"
+	CodeTooManyRequests      = "TOO_MANY_REQUESTS"
+)
+
+// Success message codes used as the message of successful envelopes.
+const (
+	MsgRegisterSuccess          = "REGISTER_SUCCESS"
+	MsgLoginSuccess             = "LOGIN_SUCCESS"
+	MsgTokenRefreshed           = "TOKEN_REFRESHED"
+	MsgLogoutSuccess            = "LOGOUT_SUCCESS"
+	MsgTicketIssued             = "WS_TICKET_ISSUED"
+	MsgAccessGranted            = "ACCESS_GRANTED"
+	MsgReviewCreated            = "REVIEW_CREATED"
+	MsgReviewsFetched           = "REVIEWS_FETCHED"
+	MsgSchedulesFetched         = "SCHEDULES_FETCHED"
+	MsgStationSchedulesFetched  = "STATION_SCHEDULES_FETCHED"
+	MsgTripSchedulesFetched     = "TRIP_SCHEDULES_FETCHED"
+	MsgStationsFetched          = "STATIONS_FETCHED"
+	MsgCacheStatsFetched        = "CACHE_STATS_FETCHED"
+	MsgAlertsFetched            = "ALERTS_FETCHED"
+	MsgAlertFetched             = "ALERT_FETCHED"
+	MsgAlertCreated             = "ALERT_CREATED"
+	MsgAlertUpdated             = "ALERT_UPDATED"
+	MsgAlertDeleted             = "ALERT_DELETED"
+	MsgDeparturesFetched        = "DEPARTURES_FETCHED"
+	MsgWebhooksFetched          = "WEBHOOKS_FETCHED"
+	MsgWebhookFetched           = "WEBHOOK_FETCHED"
+	MsgWebhookCreated           = "WEBHOOK_CREATED"
+	MsgWebhookUpdated           = "WEBHOOK_UPDATED"
+	MsgWebhookDeleted           = "WEBHOOK_DELETED"
+	MsgWebhookDeliveriesFetched = "WEBHOOK_DELIVERIES_FETCHED"
+	MsgAPIKeysFetched           = "API_KEYS_FETCHED"
+	MsgAPIKeyCreated            = "API_KEY_CREATED"
+	MsgAPIKeyRevoked            = "API_KEY_REVOKED"
+	MsgUsersFetched             = "USERS_FETCHED"
+	MsgUserFetched              = "USER_FETCHED"
+	MsgUserRoleUpdated          = "USER_ROLE_UPDATED"
+	MsgUserDisabled             = "USER_DISABLED"
+	MsgUserEnabled              = "USER_ENABLED"
+	MsgUserDeleted              = "USER_DELETED"
+	MsgPasswordChanged          = "PASSWORD_CHANGED"
+	MsgPasswordResetRequested   = "PASSWORD_RESET_REQUESTED"
+	MsgPasswordReset            = "PASSWORD_RESET"
+)
@@ -0,0 +1,132 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FieldsParam is the query parameter selecting a sparse fieldset.
+const FieldsParam = "fields"
+
+// requestedFields parses ?fields=id,jadwal into a set, or nil when absent.
+func requestedFields(c *gin.Context) map[string]bool {
+	raw := c.Query(FieldsParam)
+	if raw == "" {
+		return nil
+	}
+	fields := make(map[string]bool)
+	for _, field := range strings.Split(raw, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields[field] = true
+		}
+	}
+	return fields
+}
+
+// selectFields keeps only the requested fields of data, which is either a
+// single object or a list of objects. It fails when a requested field does
+// not exist on the resource: for structs, when no field has that JSON name,
+// so fields omitted from every object by omitempty can still be selected.
+func selectFields(data interface{}, fields map[string]bool) (interface{}, error) {
+	known := jsonFields(reflect.TypeOf(data))
+	if known != nil {
+		for field := range fields {
+			if !known[field] {
+				return nil, fmt.Errorf("unknown field %q", field)
+			}
+		}
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var objects int
+	pick := func(value interface{}) (interface{}, error) {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("resource does not support field selection")
+		}
+		objects++
+		picked := make(map[string]interface{}, len(fields))
+		for field := range fields {
+			if v, ok := object[field]; ok {
+				picked[field] = v
+				seen[field] = true
+			}
+		}
+		return picked, nil
+	}
+
+	var selected interface{}
+	switch value := decoded.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		items := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			picked, err := pick(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, picked)
+		}
+		selected = items
+	default:
+		picked, err := pick(value)
+		if err != nil {
+			return nil, err
+		}
+		selected = picked
+	}
+
+	if known == nil && objects > 0 {
+		for field := range fields {
+			if !seen[field] {
+				return nil, fmt.Errorf("unknown field %q", field)
+			}
+		}
+	}
+	return selected, nil
+}
+
+// jsonFields returns the JSON names of the fields of the struct that t, or
+// its elements when t is a list, is made of. It returns nil when t is not
+// made of structs.
+func jsonFields(t reflect.Type) map[string]bool {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch {
+		case name == "-" || !field.IsExported() && !field.Anonymous:
+			continue
+		case name == "" && field.Anonymous:
+			for embedded := range jsonFields(field.Type) {
+				fields[embedded] = true
+			}
+			continue
+		case name == "":
+			name = field.Name
+		}
+		fields[name] = true
+	}
+	return fields
+}
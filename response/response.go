@@ -0,0 +1,171 @@
+package response
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"web-scrapper/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// RequestIDKey is the gin context key holding the ID of the current request.
+const RequestIDKey = "request_id"
+
+// alertsKey is the gin context key holding the alerts set by SetAlerts.
+const alertsKey = "service_alerts"
+
+// sharedBodyKey is the gin context key set by SharedBody.
+const sharedBodyKey = "shared_body"
+
+// Envelope is the body returned by every JSON endpoint.
+type Envelope struct {
+	Success   bool        `json:"success"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data"`
+	Meta      interface{} `json:"meta,omitempty"`
+	Error     *ErrorBody  `json:"error,omitempty"`
+	Alerts    interface{} `json:"alerts,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// Page is the pagination metadata of a list response.
+type Page struct {
+	Limit      int  `json:"limit"`
+	Offset     int  `json:"offset"`
+	Total      int  `json:"total"`
+	NextOffset *int `json:"next_offset"`
+}
+
+// ErrorBody carries a machine-readable error code and optional details.
+type ErrorBody struct {
+	Code    string      `json:"code"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// FieldError describes a single request field that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func init() {
+	// Report validation errors with the JSON names clients actually send.
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// Lang returns the language negotiated for the request.
+func Lang(c *gin.Context) string {
+	if lang := c.GetString(i18n.ContextKey); lang != "" {
+		return lang
+	}
+	return i18n.Default
+}
+
+// SetAlerts attaches service alerts to the successful envelope written for
+// this request. Nothing is attached when alerts is empty.
+func SetAlerts(c *gin.Context, alerts interface{}) {
+	if value := reflect.ValueOf(alerts); value.Kind() == reflect.Slice && value.Len() == 0 {
+		return
+	}
+	c.Set(alertsKey, alerts)
+}
+
+// SharedBody leaves the request_id out of the successful envelope written for
+// this request, so that its body is the same for every client and can be
+// stored once. The ID is still sent in the X-Request-ID header.
+func SharedBody(c *gin.Context) {
+	c.Set(sharedBodyKey, true)
+}
+
+// Success writes a successful envelope whose message is the catalog entry for
+// code, formatted with args.
+func Success(c *gin.Context, status int, code string, data interface{}, args ...interface{}) {
+	SuccessWithMeta(c, status, code, data, nil, args...)
+}
+
+// SuccessWithMeta writes a successful envelope that also carries metadata,
+// such as the pagination of a list. A sparse fieldset requested through
+// ?fields= is applied to data.
+func SuccessWithMeta(c *gin.Context, status int, code string, data interface{}, meta interface{}, args ...interface{}) {
+	if fields := requestedFields(c); fields != nil {
+		selected, err := selectFields(data, fields)
+		if err != nil {
+			Error(c, http.StatusBadRequest, CodeInvalidParameter, FieldsParam)
+			return
+		}
+		data = selected
+	}
+
+	requestID := c.GetString(RequestIDKey)
+	if c.GetBool(sharedBodyKey) {
+		requestID = ""
+	}
+	alerts, _ := c.Get(alertsKey)
+	c.JSON(status, Envelope{
+		Success:   true,
+		Message:   i18n.T(Lang(c), code, args...),
+		Data:      data,
+		Meta:      meta,
+		Alerts:    alerts,
+		RequestID: requestID,
+	})
+}
+
+// Error writes a failed envelope with the given status and error code. The
+// message is the catalog entry for code, formatted with args.
+func Error(c *gin.Context, status int, code string, args ...interface{}) {
+	ErrorWithDetails(c, status, code, nil, args...)
+}
+
+// ErrorWithDetails writes a failed envelope that also carries error details,
+// such as the fields that failed validation.
+func ErrorWithDetails(c *gin.Context, status int, code string, details interface{}, args ...interface{}) {
+	c.JSON(status, Envelope{
+		Success:   false,
+		Message:   i18n.T(Lang(c), code, args...),
+		Data:      nil,
+		Error:     &ErrorBody{Code: code, Details: details},
+		RequestID: c.GetString(RequestIDKey),
+	})
+}
+
+// Abort writes a failed envelope and stops the remaining handlers of the chain.
+func Abort(c *gin.Context, status int, code string, args ...interface{}) {
+	Error(c, status, code, args...)
+	c.Abort()
+}
+
+// BindError reports a request body that could not be bound. Validation
+// failures are listed field by field in the client's language.
+func BindError(c *gin.Context, err error) {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		Error(c, http.StatusBadRequest, CodeInvalidRequest)
+		return
+	}
+
+	lang := Lang(c)
+	details := make([]FieldError, 0, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		details = append(details, FieldError{
+			Field:   fieldErr.Field(),
+			Rule:    fieldErr.Tag(),
+			Message: i18n.Validation(lang, fieldErr.Tag(), fieldErr.Field(), fieldErr.Param()),
+		})
+	}
+	ErrorWithDetails(c, http.StatusBadRequest, CodeValidationFailed, details)
+}
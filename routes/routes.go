@@ -0,0 +1,104 @@
+// Package routes registers every endpoint of the API on a router, so that
+// main and the OpenAPI tests see the same routes.
+package routes
+
+import (
+	"net/http"
+
+	"web-scrapper/auth"
+	"web-scrapper/controllers"
+	"web-scrapper/middleware"
+	"web-scrapper/models"
+	"web-scrapper/openapi"
+	"web-scrapper/realtime"
+	"web-scrapper/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Register adds the routes to router. WebSocket clients are served by hub,
+// which the caller runs.
+func Register(router *gin.Engine, hub *realtime.Hub) {
+	router.NoRoute(func(c *gin.Context) {
+		response.Error(c, http.StatusNotFound, response.CodeRouteNotFound)
+	})
+	router.POST("/api/v1/register", controllers.RegisterUser)
+	router.POST("/api/v1/login", controllers.LoginUser)
+	router.POST("/api/v1/token/refresh", controllers.RefreshToken)
+	router.POST("/api/v1/password/forgot", controllers.ForgotPassword)
+	router.POST("/api/v1/password/reset", controllers.ResetPassword)
+	router.GET("/.well-known/jwks.json", controllers.GetJWKS)
+	router.GET("/api/v1/reviews", controllers.GetAllReviews)
+	router.GET("/api/v1/alerts", controllers.GetActiveAlerts)
+	router.GET("/api/openapi.json", openapi.ServeSpec)
+	router.GET("/api/docs/*filepath", openapi.ServeSwaggerUI)
+	timetableCache := middleware.TimetableCache()                       // Cache timetable responses until the next scrape
+	timetableRead := middleware.RequireScope(models.ScopeTimetableRead) // Let API keys with the timetable:read scope through
+	// Browsers cannot set headers on WebSocket requests, so the hub also
+	// accepts a ticket from /api/v1/ws/ticket
+	router.GET("/api/v1/ws", middleware.WebSocketAuth(), timetableRead, hub.ServeWS)
+	protected := router.Group("/api")
+	protected.Use(middleware.JWTAuthMiddleware())
+	{
+		//these are for the website
+		protected.GET("/secure_endpoint", secureEndpointHandler)
+		protected.POST("/v1/logout", controllers.Logout)
+		protected.POST("/v1/me/password", controllers.ChangePassword)
+		protected.GET("/stasiun", timetableRead, timetableCache, controllers.GetAllStasiun)
+		protected.GET("/schedules", timetableRead, timetableCache, controllers.GetAllSchedules)
+		protected.GET("/schedules/:id", timetableRead, timetableCache, controllers.GetSchedulesByID)
+		protected.GET("/schedules/:id/:arah", timetableRead, timetableCache, controllers.GetSchedulesByIDAndTrip)
+
+		//these below are for sanber
+
+		protected.GET("/v1/stasiun", timetableRead, timetableCache, controllers.GetAllStasiunV1)
+		protected.GET("/v1/schedules", timetableRead, timetableCache, controllers.GetAllSchedulesV1)
+		protected.GET("/v1/schedules/:id", timetableRead, timetableCache, controllers.GetSchedulesByStationIDV1)
+		protected.GET("/v1/schedules/:id/:arah", timetableRead, timetableCache, controllers.GetSchedulesByIDAndTripV1)
+		protected.POST("/v1/reviews", controllers.CreateReview)
+		protected.GET("/v1/stations/:id/departures", timetableRead, controllers.GetNextDepartures)
+		protected.GET("/v1/stations/:id/departures/stream", timetableRead, controllers.StreamDepartures)
+		protected.POST("/v1/ws/ticket", controllers.IssueWebSocketTicket)
+		protected.GET("/v1/stations/:id/timetable.html", timetableRead, timetableCache, controllers.GetStationTimetableHTML)
+		protected.GET("/v1/stations/:id/timetable.pdf", timetableRead, timetableCache, controllers.GetStationTimetablePDF)
+
+		// these below are for admins: only roles with a permission may enter,
+		// and each group is guarded by the permission it needs
+		admin := protected.Group("/v1/admin", middleware.RequireRole(auth.StaffRoles()...))
+
+		adminAlerts := admin.Group("/alerts", middleware.RequirePermission(auth.PermAlertsManage))
+		adminAlerts.GET("", controllers.ListAlerts)
+		adminAlerts.POST("", controllers.CreateAlert)
+		adminAlerts.GET("/:id", controllers.GetAlert)
+		adminAlerts.PUT("/:id", controllers.UpdateAlert)
+		adminAlerts.DELETE("/:id", controllers.DeleteAlert)
+
+		adminWebhooks := admin.Group("/webhooks", middleware.RequirePermission(auth.PermWebhooksManage))
+		adminWebhooks.GET("", controllers.ListWebhooks)
+		adminWebhooks.POST("", controllers.CreateWebhook)
+		adminWebhooks.GET("/:id", controllers.GetWebhook)
+		adminWebhooks.PUT("/:id", controllers.UpdateWebhook)
+		adminWebhooks.DELETE("/:id", controllers.DeleteWebhook)
+		adminWebhooks.GET("/:id/deliveries", controllers.ListWebhookDeliveries)
+
+		adminAPIKeys := admin.Group("/api-keys", middleware.RequirePermission(auth.PermAPIKeysManage))
+		adminAPIKeys.GET("", controllers.ListAPIKeys)
+		adminAPIKeys.POST("", controllers.CreateAPIKey)
+		adminAPIKeys.DELETE("/:id", controllers.RevokeAPIKey)
+
+		adminUsers := admin.Group("/users", middleware.RequirePermission(auth.PermUsersManage))
+		adminUsers.GET("", controllers.ListUsers)
+		adminUsers.GET("/:id", controllers.GetUser)
+		adminUsers.PUT("/:id/role", controllers.UpdateUserRole)
+		adminUsers.POST("/:id/disable", controllers.DisableUser)
+		adminUsers.POST("/:id/enable", controllers.EnableUser)
+		adminUsers.DELETE("/:id", controllers.DeleteUser)
+
+		adminCache := admin.Group("/cache", middleware.RequirePermission(auth.PermOperationsRead))
+		adminCache.GET("/stats", controllers.GetCacheStats)
+	}
+}
+
+func secureEndpointHandler(c *gin.Context) {
+	response.Success(c, http.StatusOK, response.MsgAccessGranted, nil)
+}
@@ -0,0 +1,164 @@
+package scraping
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"web-scrapper/models"
+
+	"github.com/gocolly/colly"
+)
+
+// DefaultAnnouncementsURL is the operator's news page listing service
+// announcements.
+const DefaultAnnouncementsURL = "https://jakartamrt.co.id/id/info-terkini"
+
+// announcementSelector matches one entry of the news listing. The site has
+// changed its markup before, so the common card containers are all accepted
+// and duplicates from nested matches are dropped by URL.
+const announcementSelector = ".views-row, article, .card, .news-item"
+
+// alertDuration is how long an imported announcement stays active.
+const alertDuration = 24 * time.Hour
+
+// disruptionKeywords mark an announcement as a service disruption rather than
+// general news.
+var disruptionKeywords = []string{
+	"gangguan", "terlambat", "keterlambatan", "penyesuaian jadwal", "perubahan jadwal",
+	"penutupan", "ditutup", "perbaikan", "pemeliharaan", "rekayasa operasi",
+}
+
+// warningKeywords raise an imported alert from info to warning.
+var warningKeywords = []string{"gangguan", "terlambat", "keterlambatan", "penutupan", "ditutup"}
+
+var indonesianMonths = strings.NewReplacer(
+	"Januari", "January", "Februari", "February", "Maret", "March", "Mei", "May",
+	"Juni", "June", "Juli", "July", "Agustus", "August", "Oktober", "October",
+	"Desember", "December",
+)
+
+// Announcement is an entry of the operator's news page.
+type Announcement struct {
+	URL         string
+	Title       string
+	Summary     string
+	PublishedAt time.Time // zero when the listing shows no date
+}
+
+// ContentHash identifies the text of the announcement independently of its URL.
+func (a Announcement) ContentHash() string {
+	sum := sha256.Sum256([]byte(strings.ToLower(a.Title) + "\n" + strings.ToLower(a.Summary)))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsDisruption reports whether the announcement is about the train service.
+func (a Announcement) IsDisruption() bool {
+	return containsAny(a.Title+" "+a.Summary, disruptionKeywords)
+}
+
+// AlertInput converts the announcement to a network-wide service alert. The
+// operator only publishes in Indonesian, so the English message is left empty
+// and English clients get the Indonesian text.
+func (a Announcement) AlertInput() models.ServiceAlertInput {
+	severity := models.SeverityInfo
+	if containsAny(a.Title+" "+a.Summary, warningKeywords) {
+		severity = models.SeverityWarning
+	}
+
+	startsAt := a.PublishedAt
+	if startsAt.IsZero() {
+		startsAt = time.Now()
+	}
+	endsAt := startsAt.Add(alertDuration)
+
+	message := a.Title
+	if a.Summary != "" {
+		message += ": " + a.Summary
+	}
+	if runes := []rune(message); len(runes) > 2000 {
+		message = string(runes[:2000])
+	}
+	return models.ServiceAlertInput{
+		Severity:  severity,
+		MessageID: message,
+		StartsAt:  &startsAt,
+		EndsAt:    &endsAt,
+	}
+}
+
+// ScrapeAnnouncements collects the announcements listed on the news page at
+// listURL, in page order.
+func ScrapeAnnouncements(listURL string) ([]Announcement, error) {
+	var announcements []Announcement
+	seen := make(map[string]bool)
+
+	c := colly.NewCollector()
+	c.SetRequestTimeout(30 * time.Second)
+
+	c.OnHTML(announcementSelector, func(e *colly.HTMLElement) {
+		link := e.ChildAttr("a[href]", "href")
+		title := collapseSpaces(e.ChildText("h1, h2, h3, h4, h5, .title"))
+		if link == "" || title == "" {
+			return
+		}
+		url := e.Request.AbsoluteURL(link)
+		if seen[url] {
+			return
+		}
+		seen[url] = true
+
+		announcements = append(announcements, Announcement{
+			URL:         url,
+			Title:       title,
+			Summary:     collapseSpaces(e.ChildText("p, .summary, .field-content")),
+			PublishedAt: parseIndonesianDate(e.ChildText("time, .date")),
+		})
+	})
+
+	var scrapeErr error
+	c.OnError(func(r *colly.Response, err error) {
+		scrapeErr = fmt.Errorf("error visiting %s: %v", r.Request.URL, err)
+	})
+
+	if err := c.Visit(listURL); err != nil {
+		return nil, fmt.Errorf("error visiting %s: %v", listURL, err)
+	}
+	c.Wait()
+	if scrapeErr != nil {
+		return nil, scrapeErr
+	}
+	return announcements, nil
+}
+
+// parseIndonesianDate parses dates such as "5 Maret 2024" in Asia/Jakarta
+// time and returns the zero time when the text is not a date.
+func parseIndonesianDate(text string) time.Time {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		loc = time.UTC
+	}
+	text = indonesianMonths.Replace(collapseSpaces(text))
+	for _, layout := range []string{"2 January 2006", "02 January 2006", "2006-01-02"} {
+		if date, err := time.ParseInLocation(layout, text, loc); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
+
+func containsAny(text string, keywords []string) bool {
+	text = strings.ToLower(text)
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func collapseSpaces(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
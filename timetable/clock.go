@@ -0,0 +1,54 @@
+package timetable
+
+import (
+	"log"
+	"time"
+
+	"web-scrapper/models"
+)
+
+// location is the timezone the timetable and the nightly scrape run in.
+var location = loadLocation()
+
+func loadLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		log.Printf("Error loading Asia/Jakarta, falling back to UTC+7: %v", err)
+		return time.FixedZone("WIB", 7*60*60)
+	}
+	return loc
+}
+
+// Location returns the Asia/Jakarta timezone.
+func Location() *time.Location {
+	return location
+}
+
+// NextRefresh returns when the nightly scrape will next replace the
+// timetable, which is the next midnight in Jakarta.
+func NextRefresh(now time.Time) time.Time {
+	local := now.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, location)
+}
+
+// DayType returns the timetable day type in effect at the given time.
+func DayType(now time.Time) string {
+	switch now.In(location).Weekday() {
+	case time.Saturday, time.Sunday:
+		return models.DayWeekend
+	}
+	return models.DayWeekday
+}
+
+// DayTypeSince returns when the day type in effect at the given time took
+// effect: the last Saturday midnight on weekends and the last Monday midnight
+// on weekdays, in Jakarta.
+func DayTypeSince(now time.Time) time.Time {
+	local := now.In(location)
+	first := time.Monday
+	if DayType(now) == models.DayWeekend {
+		first = time.Saturday
+	}
+	days := (int(local.Weekday()) - int(first) + 7) % 7
+	return time.Date(local.Year(), local.Month(), local.Day()-days, 0, 0, 0, 0, location)
+}
@@ -0,0 +1,174 @@
+package timetable
+
+import (
+	"sort"
+	"time"
+
+	"web-scrapper/models"
+)
+
+// Index is an immutable snapshot of the active timetable, built by Refresh at
+// startup and after every scrape so that read endpoints never query the
+// database. Slices returned by its methods are shared and must not be
+// modified.
+type Index struct {
+	version   Version
+	stations  []models.Stasiun
+	schedules []models.Schedule
+	byStation map[int][]models.Schedule
+	// trips holds station -> direction -> day type -> rows ordered by ID
+	trips map[int]map[string]map[string][]models.Schedule
+	// times holds station -> direction -> day type -> sorted departure times
+	times map[int]map[string]map[string][]string
+}
+
+// NewIndex builds an index from the rows of the stations and schedules
+// tables, both ordered by ID.
+func NewIndex(version Version, stations []models.Stasiun, schedules []models.Schedule) *Index {
+	index := &Index{
+		version:   version,
+		stations:  stations,
+		schedules: schedules,
+		byStation: make(map[int][]models.Schedule),
+		trips:     make(map[int]map[string]map[string][]models.Schedule),
+		times:     make(map[int]map[string]map[string][]string),
+	}
+
+	seen := make(map[int]map[string]map[string]map[string]bool)
+	for _, schedule := range schedules {
+		station, arah, day := schedule.StasiunID, schedule.Arah, schedule.DayType()
+		index.byStation[station] = append(index.byStation[station], schedule)
+
+		if index.trips[station] == nil {
+			index.trips[station] = make(map[string]map[string][]models.Schedule)
+			index.times[station] = make(map[string]map[string][]string)
+			seen[station] = make(map[string]map[string]map[string]bool)
+		}
+		if index.trips[station][arah] == nil {
+			index.trips[station][arah] = make(map[string][]models.Schedule)
+			index.times[station][arah] = make(map[string][]string)
+			seen[station][arah] = make(map[string]map[string]bool)
+		}
+		if seen[station][arah][day] == nil {
+			seen[station][arah][day] = make(map[string]bool)
+		}
+		index.trips[station][arah][day] = append(index.trips[station][arah][day], schedule)
+
+		// The operator lists some departures twice; keep one time each.
+		if !seen[station][arah][day][schedule.Jadwal] {
+			seen[station][arah][day][schedule.Jadwal] = true
+			index.times[station][arah][day] = append(index.times[station][arah][day], schedule.Jadwal)
+		}
+	}
+	for _, directions := range index.times {
+		for _, days := range directions {
+			for _, times := range days {
+				sort.Strings(times)
+			}
+		}
+	}
+	return index
+}
+
+// Version returns the timetable version the index was built from.
+func (i *Index) Version() Version {
+	return i.version
+}
+
+// Stations returns every station ordered by ID.
+func (i *Index) Stations() []models.Stasiun {
+	return i.stations
+}
+
+// Station returns the station with the given ID.
+func (i *Index) Station(id int) (models.Stasiun, bool) {
+	n := sort.Search(len(i.stations), func(k int) bool { return i.stations[k].StasiunID >= id })
+	if n < len(i.stations) && i.stations[n].StasiunID == id {
+		return i.stations[n], true
+	}
+	return models.Stasiun{}, false
+}
+
+// Schedules returns every schedule row ordered by ID.
+func (i *Index) Schedules() []models.Schedule {
+	return i.schedules
+}
+
+// StationSchedules returns the rows of a station, both directions and day
+// types, ordered by ID.
+func (i *Index) StationSchedules(stationID int) []models.Schedule {
+	return i.byStation[stationID]
+}
+
+// TripSchedules returns the rows of a station in one direction for one day
+// type, ordered by ID.
+func (i *Index) TripSchedules(stationID int, arah string, dayType string) []models.Schedule {
+	return i.trips[stationID][arah][dayType]
+}
+
+// Directions returns the directions served at a station, sorted by name.
+func (i *Index) Directions(stationID int) []string {
+	directions := make([]string, 0, len(i.times[stationID]))
+	for arah := range i.times[stationID] {
+		directions = append(directions, arah)
+	}
+	sort.Strings(directions)
+	return directions
+}
+
+// Times returns the sorted, distinct departure times of a station in one
+// direction for one day type.
+func (i *Index) Times(stationID int, arah string, dayType string) []string {
+	return i.times[stationID][arah][dayType]
+}
+
+// NextDepartures returns up to n departures from a station in one direction
+// at or after now, continuing into the next day's timetable when today's has
+// run out.
+func (i *Index) NextDepartures(stationID int, arah string, now time.Time, n int) []models.Departure {
+	local := now.In(location)
+	departures := make([]models.Departure, 0, n)
+
+	for day := 0; day < 2 && len(departures) < n; day++ {
+		date := time.Date(local.Year(), local.Month(), local.Day()+day, 0, 0, 0, 0, location)
+		dayType := DayType(date)
+		times := i.Times(stationID, arah, dayType)
+
+		start := 0
+		if day == 0 {
+			start = sort.SearchStrings(times, local.Format("15:04"))
+		}
+		for _, jadwal := range times[start:] {
+			if len(departures) == n {
+				break
+			}
+			departsAt, err := time.ParseInLocation("15:04", jadwal, location)
+			if err != nil {
+				continue
+			}
+			departsAt = date.Add(time.Duration(departsAt.Hour())*time.Hour + time.Duration(departsAt.Minute())*time.Minute)
+			departures = append(departures, models.Departure{
+				StationID:    stationID,
+				Arah:         arah,
+				Jadwal:       jadwal,
+				DayType:      dayType,
+				DepartsAt:    departsAt,
+				MinutesUntil: int(departsAt.Sub(local.Truncate(time.Minute)) / time.Minute),
+			})
+		}
+	}
+	return departures
+}
+
+// UpcomingDepartures merges the next n departures of each direction in
+// departure order.
+func (i *Index) UpcomingDepartures(stationID int, directions []string, now time.Time, n int) []models.Departure {
+	departures := []models.Departure{}
+	for _, arah := range directions {
+		departures = append(departures, i.NextDepartures(stationID, arah, now, n)...)
+	}
+	sort.SliceStable(departures, func(a, b int) bool {
+		return departures[a].DepartsAt.Before(departures[b].DepartsAt)
+	})
+	return departures
+}
@@ -0,0 +1,40 @@
+package timetable
+
+import "sync"
+
+// subscribers receive the version of every index swapped in by Refresh.
+var subscribers = struct {
+	sync.Mutex
+	channels map[chan Version]struct{}
+}{channels: make(map[chan Version]struct{})}
+
+// Subscribe returns a channel that receives the version of each timetable
+// swapped in by Refresh, and a function that ends the subscription. Only the
+// latest version is kept for a subscriber that falls behind.
+func Subscribe() (<-chan Version, func()) {
+	updates := make(chan Version, 1)
+
+	subscribers.Lock()
+	subscribers.channels[updates] = struct{}{}
+	subscribers.Unlock()
+
+	return updates, func() {
+		subscribers.Lock()
+		delete(subscribers.channels, updates)
+		subscribers.Unlock()
+	}
+}
+
+func notify(version Version) {
+	subscribers.Lock()
+	defer subscribers.Unlock()
+
+	for updates := range subscribers.channels {
+		// Replace a version the subscriber has not read yet.
+		select {
+		case <-updates:
+		default:
+		}
+		updates <- version
+	}
+}
@@ -0,0 +1,133 @@
+package timetable
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"web-scrapper/models"
+)
+
+// Version identifies the active timetable. ETag changes whenever the
+// schedules or stations change; LastModified is when that version was stored.
+type Version struct {
+	ETag         string
+	LastModified time.Time
+}
+
+// IsZero reports whether no version has been loaded yet.
+func (v Version) IsZero() bool {
+	return v.ETag == ""
+}
+
+var (
+	mu      sync.RWMutex
+	current Version
+	index   = NewIndex(Version{}, nil, nil)
+)
+
+// Current returns the version of the active timetable.
+func Current() Version {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// CurrentIndex returns the in-memory index of the active timetable. It is
+// empty until the first Refresh.
+func CurrentIndex() *Index {
+	mu.RLock()
+	defer mu.RUnlock()
+	return index
+}
+
+// Refresh loads the stations and schedules tables, makes their hash the
+// current version and swaps in a new index built from them. A new row is
+// recorded in timetable_versions when the hash differs from the last one, so
+// LastModified survives restarts. It is called at startup and after every
+// successful scrape.
+func Refresh(db *sql.DB) (Version, error) {
+	stations, schedules, err := load(db)
+	if err != nil {
+		return Version{}, err
+	}
+	etag := hashTimetable(stations, schedules)
+
+	var version Version
+	err = db.QueryRow("SELECT etag, scraped_at FROM timetable_versions ORDER BY id DESC LIMIT 1").Scan(&version.ETag, &version.LastModified)
+	if err != nil && err != sql.ErrNoRows {
+		return Version{}, fmt.Errorf("error reading timetable version: %v", err)
+	}
+
+	if err == sql.ErrNoRows || version.ETag != etag {
+		version.ETag = etag
+		err = db.QueryRow("INSERT INTO timetable_versions (etag) VALUES ($1) RETURNING scraped_at", etag).Scan(&version.LastModified)
+		if err != nil {
+			return Version{}, fmt.Errorf("error recording timetable version: %v", err)
+		}
+		log.Printf("Timetable version changed to %s", etag)
+	}
+
+	// HTTP dates have second precision.
+	version.LastModified = version.LastModified.UTC().Truncate(time.Second)
+
+	next := NewIndex(version, stations, schedules)
+	mu.Lock()
+	current = version
+	index = next
+	mu.Unlock()
+	log.Printf("Timetable index loaded: %d stations, %d schedules", len(stations), len(schedules))
+	notify(version)
+	return version, nil
+}
+
+// load reads both timetable tables ordered by ID.
+func load(db *sql.DB) ([]models.Stasiun, []models.Schedule, error) {
+	rows, err := db.Query("SELECT id, stasiun_name FROM stations ORDER BY id")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading stations: %v", err)
+	}
+	stations := []models.Stasiun{}
+	for rows.Next() {
+		var station models.Stasiun
+		if err := rows.Scan(&station.StasiunID, &station.StasiunName); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("error loading stations: %v", err)
+		}
+		stations = append(stations, station)
+	}
+	rows.Close()
+
+	rows, err = db.Query("SELECT id, station_id, COALESCE(stasiun_name, ''), arah, to_char(jadwal, 'HH24:MI') FROM schedules ORDER BY id")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading schedules: %v", err)
+	}
+	defer rows.Close()
+	schedules := []models.Schedule{}
+	for rows.Next() {
+		var schedule models.Schedule
+		if err := rows.Scan(&schedule.ID, &schedule.StasiunID, &schedule.StasiunName, &schedule.Arah, &schedule.Jadwal); err != nil {
+			return nil, nil, fmt.Errorf("error loading schedules: %v", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error loading schedules: %v", err)
+	}
+	return stations, schedules, nil
+}
+
+func hashTimetable(stations []models.Stasiun, schedules []models.Schedule) string {
+	hash := sha256.New()
+	for _, station := range stations {
+		fmt.Fprintf(hash, "s|%d|%s\n", station.StasiunID, station.StasiunName)
+	}
+	for _, s := range schedules {
+		fmt.Fprintf(hash, "j|%d|%d|%s|%s|%s\n", s.ID, s.StasiunID, s.StasiunName, s.Arah, s.Jadwal)
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:32]
+}
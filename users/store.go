@@ -0,0 +1,172 @@
+// Package users manages user accounts on behalf of admins.
+package users
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"web-scrapper/models"
+)
+
+// Policies for the reviews of a deleted user.
+const (
+	// ReviewsAnonymize keeps the reviews without their author.
+	ReviewsAnonymize = "anonymize"
+	// ReviewsDelete deletes the reviews with the user.
+	ReviewsDelete = "delete"
+)
+
+const columns = `u.id, u.username, COALESCE(u.email, ''), COALESCE(u.role, ''), u.disabled_at, COALESCE(u.created_at, NOW()),
+	(SELECT COUNT(*) FROM reviews r WHERE r.user_id = u.id)`
+
+// Filter narrows a user listing. Empty fields match every user.
+type Filter struct {
+	// Query matches part of the username or email, ignoring case.
+	Query    string
+	Role     string
+	Disabled *bool
+}
+
+func (f Filter) where() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if f.Query != "" {
+		args = append(args, "%"+escapeLike(f.Query)+"%")
+		conditions = append(conditions, fmt.Sprintf("(u.username ILIKE $%d OR u.email ILIKE $%d)", len(args), len(args)))
+	}
+	if f.Role != "" {
+		args = append(args, f.Role)
+		conditions = append(conditions, fmt.Sprintf("COALESCE(u.role, '') = $%d", len(args)))
+	}
+	if f.Disabled != nil {
+		if *f.Disabled {
+			conditions = append(conditions, "u.disabled_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "u.disabled_at IS NULL")
+		}
+	}
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scan(row scanner) (models.UserAccount, error) {
+	var user models.UserAccount
+	var disabledAt sql.NullTime
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &disabledAt, &user.CreatedAt, &user.ReviewCount)
+	if err != nil {
+		return user, err
+	}
+	if disabledAt.Valid {
+		user.Disabled = true
+		user.DisabledAt = &disabledAt.Time
+	}
+	return user, nil
+}
+
+// List returns one page of the users matching filter, ordered by ID, and
+// their total number.
+func List(db *sql.DB, filter Filter, limit int, offset int) ([]models.UserAccount, int, error) {
+	where, args := filter.where()
+
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users u"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, limit, offset)
+	rows, err := db.Query(fmt.Sprintf("SELECT %s FROM users u%s ORDER BY u.id LIMIT $%d OFFSET $%d",
+		columns, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []models.UserAccount{}
+	for rows.Next() {
+		user, err := scan(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	return users, total, rows.Err()
+}
+
+// Get returns the user with the given ID, or sql.ErrNoRows.
+func Get(db *sql.DB, id int) (models.UserAccount, error) {
+	return scan(db.QueryRow("SELECT "+columns+" FROM users u WHERE u.id = $1", id))
+}
+
+// SetRole changes the role of a user. It returns sql.ErrNoRows when the user
+// does not exist.
+func SetRole(db *sql.DB, id int, role string) (models.UserAccount, error) {
+	return update(db, "UPDATE users SET role = $2 WHERE id = $1", id, role)
+}
+
+// SetDisabled disables or enables an account. Disabled users are refused by
+// the auth middleware at once, and their sessions are ended by the caller.
+// Disabling an already disabled account keeps its original date. It returns
+// sql.ErrNoRows when the user does not exist.
+func SetDisabled(db *sql.DB, id int, disabled bool) (models.UserAccount, error) {
+	if disabled {
+		return update(db, "UPDATE users SET disabled_at = COALESCE(disabled_at, NOW()) WHERE id = $1", id)
+	}
+	return update(db, "UPDATE users SET disabled_at = NULL WHERE id = $1", id)
+}
+
+// Delete removes a user and handles their reviews according to policy,
+// either ReviewsAnonymize or ReviewsDelete. Sessions, refresh tokens and
+// authorship of alerts, webhooks and API keys follow the foreign keys. It
+// returns sql.ErrNoRows when the user does not exist.
+func Delete(db *sql.DB, id int, policy string) error {
+	var reviews string
+	switch policy {
+	case ReviewsAnonymize:
+		reviews = "UPDATE reviews SET user_id = NULL WHERE user_id = $1"
+	case ReviewsDelete:
+		reviews = "DELETE FROM reviews WHERE user_id = $1"
+	default:
+		return fmt.Errorf("unknown review policy %q", policy)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(reviews, id); err != nil {
+		return fmt.Errorf("error handling reviews: %v", err)
+	}
+	result, err := tx.Exec("DELETE FROM users WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("error deleting user: %v", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+func update(db *sql.DB, statement string, id int, args ...interface{}) (models.UserAccount, error) {
+	result, err := db.Exec(statement, append([]interface{}{id}, args...)...)
+	if err != nil {
+		return models.UserAccount{}, err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return models.UserAccount{}, sql.ErrNoRows
+	}
+	return Get(db, id)
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
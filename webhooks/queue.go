@@ -0,0 +1,235 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"web-scrapper/models"
+)
+
+const (
+	// batchSize is the number of due deliveries claimed at once.
+	batchSize = 20
+	// lease hides claimed deliveries from other replicas while they are
+	// attempted. It must outlast a batch of timed out requests.
+	lease = 5 * time.Minute
+	// maxAttempts is the number of attempts before a delivery is given up.
+	maxAttempts = 10
+	// firstRetry is the delay before the second attempt; it doubles after
+	// every failure up to maxRetry.
+	firstRetry = 30 * time.Second
+	maxRetry   = 6 * time.Hour
+)
+
+// client sends the deliveries. Receivers must answer quickly and process the
+// event afterwards.
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// wake nudges Dispatch to deliver events published on this replica right away.
+var wake = make(chan struct{}, 1)
+
+// Payload is the JSON body posted to webhooks.
+type Payload struct {
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// Publish queues an event for every active webhook subscribed to it. The
+// deliveries survive restarts and are sent by Dispatch.
+func Publish(db *sql.DB, event string, data interface{}) error {
+	payload, err := json.Marshal(Payload{Event: event, OccurredAt: time.Now(), Data: data})
+	if err != nil {
+		return fmt.Errorf("error encoding %s event: %v", event, err)
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO webhook_deliveries (webhook_id, event, payload)
+		SELECT id, $1, $2 FROM webhooks WHERE active AND $1 = ANY(events)`, event, payload)
+	if err != nil {
+		return fmt.Errorf("error queueing %s event: %v", event, err)
+	}
+	if queued, err := result.RowsAffected(); err == nil && queued > 0 {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Sign returns the X-Webhook-Signature of a delivery: the hex HMAC-SHA256,
+// keyed with the webhook secret, of the X-Webhook-Timestamp, a dot and the
+// body. Receivers recompute it to check the sender and reject old timestamps
+// to stop replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatch sends due deliveries every interval, and as soon as an event is
+// published on this replica. It blocks for the lifetime of the process.
+func Dispatch(db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			sent, err := DeliverDue(db)
+			if err != nil {
+				log.Printf("Error delivering webhooks: %v", err)
+				break
+			}
+			if sent < batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// DeliverDue attempts one batch of the deliveries whose time has come and
+// returns how many were attempted. Deliveries are claimed with SKIP LOCKED so
+// replicas never send the same one concurrently.
+func DeliverDue(db *sql.DB) (int, error) {
+	rows, err := db.Query(`
+		WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = $1 AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE webhook_deliveries d
+			SET next_attempt_at = NOW() + $3 * INTERVAL '1 second'
+			FROM due WHERE d.id = due.id
+			RETURNING d.id, d.webhook_id, d.event, d.payload, d.attempts
+		)
+		SELECT c.id, c.event, c.payload, c.attempts, w.url, w.secret
+		FROM claimed c JOIN webhooks w ON w.id = c.webhook_id`,
+		models.DeliveryPending, batchSize, int(lease/time.Second))
+	if err != nil {
+		return 0, fmt.Errorf("error claiming deliveries: %v", err)
+	}
+
+	type claim struct {
+		id       int
+		event    string
+		payload  []byte
+		attempts int
+		url      string
+		secret   string
+	}
+	var claims []claim
+	for rows.Next() {
+		var d claim
+		if err := rows.Scan(&d.id, &d.event, &d.payload, &d.attempts, &d.url, &d.secret); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		claims = append(claims, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, d := range claims {
+		statusCode, err := deliver(d.url, d.secret, d.id, d.event, d.payload)
+		if err := record(db, d.id, d.attempts+1, statusCode, err); err != nil {
+			log.Printf("Error recording webhook delivery %d: %v", d.id, err)
+		}
+	}
+	return len(claims), nil
+}
+
+// deliver posts a payload and returns the status code of the answer. Any
+// answer outside 2xx is an error.
+func deliver(url string, secret string, id int, event string, payload []byte) (int, error) {
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "mrt-api-webhooks")
+	request.Header.Set("X-Webhook-Event", event)
+	request.Header.Set("X-Webhook-Delivery", strconv.Itoa(id))
+	request.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	request.Header.Set("X-Webhook-Signature", Sign(secret, timestamp, payload))
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// record stores the outcome of an attempt, scheduling the next one with
+// exponential backoff or giving up after maxAttempts.
+func record(db *sql.DB, id int, attempts int, statusCode int, deliveryErr error) error {
+	var code sql.NullInt64
+	if statusCode != 0 {
+		code = sql.NullInt64{Int64: int64(statusCode), Valid: true}
+	}
+
+	status, retryIn := outcome(attempts, deliveryErr)
+	if status == models.DeliveryDelivered {
+		_, err := db.Exec(`
+			UPDATE webhook_deliveries
+			SET status = $2, attempts = $3, last_status_code = $4, last_error = NULL, delivered_at = NOW()
+			WHERE id = $1`, id, status, attempts, code)
+		return err
+	}
+
+	_, err := db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, last_status_code = $4, last_error = $5, next_attempt_at = $6
+		WHERE id = $1`, id, status, attempts, code, deliveryErr.Error(), time.Now().Add(retryIn))
+	return err
+}
+
+// outcome returns the status of a delivery after the given number of
+// attempts, the last of which ended with deliveryErr, and when a pending
+// delivery is retried.
+func outcome(attempts int, deliveryErr error) (string, time.Duration) {
+	if deliveryErr == nil {
+		return models.DeliveryDelivered, 0
+	}
+	if attempts >= maxAttempts {
+		return models.DeliveryFailed, 0
+	}
+	return models.DeliveryPending, backoff(attempts)
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func backoff(attempts int) time.Duration {
+	delay := firstRetry
+	for i := 1; i < attempts && delay < maxRetry; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetry)
+}
@@ -0,0 +1,137 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"web-scrapper/models"
+)
+
+// receiver is a webhook endpoint answering with status and keeping the last
+// request it got.
+type receiver struct {
+	*httptest.Server
+	status  int
+	request *http.Request
+	body    []byte
+}
+
+func newReceiver(t *testing.T, status int) *receiver {
+	t.Helper()
+	r := &receiver{status: status}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Errorf("reading delivery body: %v", err)
+		}
+		r.request, r.body = req, body
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestDeliverSignsPayload(t *testing.T) {
+	r := newReceiver(t, http.StatusNoContent)
+	payload := []byte(`{"event":"alert.created","data":{"id":1}}`)
+
+	before := time.Now().Unix()
+	statusCode, err := deliver(r.URL, "s3cret", 42, models.EventAlertCreated, payload)
+	if err != nil || statusCode != http.StatusNoContent {
+		t.Fatalf("deliver = %d, %v; want 204, nil", statusCode, err)
+	}
+
+	header := r.request.Header
+	if got := header.Get("X-Webhook-Event"); got != models.EventAlertCreated {
+		t.Errorf("X-Webhook-Event = %q, want %q", got, models.EventAlertCreated)
+	}
+	if got := header.Get("X-Webhook-Delivery"); got != "42" {
+		t.Errorf("X-Webhook-Delivery = %q, want 42", got)
+	}
+	if got := header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	timestamp, err := strconv.ParseInt(header.Get("X-Webhook-Timestamp"), 10, 64)
+	if err != nil || timestamp < before || timestamp > time.Now().Unix() {
+		t.Errorf("X-Webhook-Timestamp = %q, want the time of delivery", header.Get("X-Webhook-Timestamp"))
+	}
+	if string(r.body) != string(payload) {
+		t.Errorf("body = %s, want %s", r.body, payload)
+	}
+
+	// Check the signature the way a receiver would.
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(header.Get("X-Webhook-Timestamp") + "." + string(r.body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := header.Get("X-Webhook-Signature"); got != want {
+		t.Errorf("X-Webhook-Signature = %q, want %q", got, want)
+	}
+	if Sign("other", timestamp, payload) == want {
+		t.Error("signature does not depend on the secret")
+	}
+}
+
+func TestNon2xxIsRetried(t *testing.T) {
+	for _, status := range []int{http.StatusMovedPermanently, http.StatusBadRequest, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		r := newReceiver(t, status)
+		statusCode, err := deliver(r.URL, "s3cret", 1, models.EventScrapeFailed, []byte(`{}`))
+		if err == nil || statusCode != status {
+			t.Errorf("deliver to a %d receiver = %d, %v; want %d and an error", status, statusCode, err, status)
+			continue
+		}
+		if state, retryIn := outcome(1, err); state != models.DeliveryPending || retryIn != firstRetry {
+			t.Errorf("outcome after a %d = %s in %v, want %s in %v", status, state, retryIn, models.DeliveryPending, firstRetry)
+		}
+	}
+
+	r := newReceiver(t, http.StatusOK)
+	r.Close()
+	if statusCode, err := deliver(r.URL, "s3cret", 1, models.EventScrapeFailed, []byte(`{}`)); err == nil || statusCode != 0 {
+		t.Errorf("deliver to an unreachable receiver = %d, %v; want 0 and an error", statusCode, err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{9, 128 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, maxRetry},
+		{50, maxRetry},
+	}
+	for _, test := range tests {
+		if got := backoff(test.attempts); got != test.want {
+			t.Errorf("backoff(%d) = %v, want %v", test.attempts, got, test.want)
+		}
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	r := newReceiver(t, http.StatusBadGateway)
+	_, deliveryErr := deliver(r.URL, "s3cret", 1, models.EventTimetableUpdated, []byte(`{}`))
+
+	for attempts := 1; attempts < maxAttempts; attempts++ {
+		if state, retryIn := outcome(attempts, deliveryErr); state != models.DeliveryPending || retryIn != backoff(attempts) {
+			t.Errorf("outcome(%d, err) = %s in %v, want %s in %v", attempts, state, retryIn, models.DeliveryPending, backoff(attempts))
+		}
+	}
+	if state, _ := outcome(maxAttempts, deliveryErr); state != models.DeliveryFailed {
+		t.Errorf("outcome(%d, err) = %s, want %s", maxAttempts, state, models.DeliveryFailed)
+	}
+	if state, _ := outcome(maxAttempts, nil); state != models.DeliveryDelivered {
+		t.Errorf("outcome(%d, nil) = %s, want %s", maxAttempts, state, models.DeliveryDelivered)
+	}
+}
@@ -0,0 +1,186 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+
+	"web-scrapper/models"
+
+	"github.com/lib/pq"
+)
+
+const columns = "id, url, events, active, created_by, created_at, updated_at"
+
+const deliveryColumns = "id, webhook_id, event, payload, status, attempts, next_attempt_at, last_status_code, COALESCE(last_error, ''), created_at, delivered_at"
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scan(row scanner) (models.Webhook, error) {
+	var webhook models.Webhook
+	var events pq.StringArray
+	var createdBy sql.NullInt64
+	err := row.Scan(&webhook.ID, &webhook.URL, &events, &webhook.Active, &createdBy, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return webhook, err
+	}
+
+	webhook.Events = []string(events)
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		webhook.CreatedBy = &id
+	}
+	return webhook, nil
+}
+
+func scanDelivery(row scanner) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var payload []byte
+	var nextAttemptAt, deliveredAt sql.NullTime
+	var statusCode sql.NullInt64
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &payload, &delivery.Status, &delivery.Attempts,
+		&nextAttemptAt, &statusCode, &delivery.LastError, &delivery.CreatedAt, &deliveredAt)
+	if err != nil {
+		return delivery, err
+	}
+
+	delivery.Payload = payload
+	// The next attempt is only meaningful while the delivery is queued.
+	if nextAttemptAt.Valid && delivery.Status == models.DeliveryPending {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if statusCode.Valid {
+		code := int(statusCode.Int64)
+		delivery.LastStatusCode = &code
+	}
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return delivery, nil
+}
+
+// List returns one page of the webhooks, oldest first, and their total number.
+func List(db *sql.DB, limit int, offset int) ([]models.Webhook, int, error) {
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM webhooks").Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Query("SELECT "+columns+" FROM webhooks ORDER BY id LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		webhook, err := scan(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, total, rows.Err()
+}
+
+// Get returns the webhook with the given ID, or sql.ErrNoRows.
+func Get(db *sql.DB, id int) (models.Webhook, error) {
+	return scan(db.QueryRow("SELECT "+columns+" FROM webhooks WHERE id = $1", id))
+}
+
+// Create registers a webhook with a new random secret, which is returned in
+// the result and cannot be read back later.
+func Create(db *sql.DB, input models.WebhookInput, createdBy int) (models.Webhook, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	active := true
+	if input.Active != nil {
+		active = *input.Active
+	}
+	var creator sql.NullInt64
+	if createdBy != 0 {
+		creator = sql.NullInt64{Int64: int64(createdBy), Valid: true}
+	}
+
+	webhook, err := scan(db.QueryRow(`
+		INSERT INTO webhooks (url, events, secret, active, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+columns,
+		input.URL, pq.StringArray(input.Events), secret, active, creator))
+	if err != nil {
+		return webhook, fmt.Errorf("error creating webhook: %v", err)
+	}
+	webhook.Secret = secret
+	return webhook, nil
+}
+
+// Update replaces the URL and events of a webhook and, when input.Active is
+// set, activates or pauses it. It returns sql.ErrNoRows when the webhook does
+// not exist.
+func Update(db *sql.DB, id int, input models.WebhookInput) (models.Webhook, error) {
+	return scan(db.QueryRow(`
+		UPDATE webhooks
+		SET url = $2, events = $3, active = COALESCE($4, active), updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+columns,
+		id, input.URL, pq.StringArray(input.Events), input.Active))
+}
+
+// Delete removes a webhook and its deliveries. It returns sql.ErrNoRows when
+// the webhook does not exist.
+func Delete(db *sql.DB, id int) error {
+	result, err := db.Exec("DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Deliveries returns one page of the deliveries of a webhook, newest first,
+// optionally only those with the given status, and their total number.
+func Deliveries(db *sql.DB, webhookID int, status string, limit int, offset int) ([]models.WebhookDelivery, int, error) {
+	var total int
+	err := db.QueryRow("SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1 AND ($2 = '' OR status = $2)",
+		webhookID, status).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Query(`
+		SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC LIMIT $3 OFFSET $4`, webhookID, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, total, rows.Err()
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("error generating webhook secret: %v", err)
+	}
+	return hex.EncodeToString(secret), nil
+}